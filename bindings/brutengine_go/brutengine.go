@@ -1,30 +1,204 @@
 // Code generated by 'go run generate.go'; DO NOT EDIT.
 package brutengine_go
 
+import "unsafe"
+
 // Enums
 
+type Atlas uint32
+
+type Bus uint32
+
+const (
+	BusMaster Bus = 0
+	BusMusic  Bus = 1
+	BusSfx    Bus = 2
+	BusVoice  Bus = 3
+)
+
+type CursorMode uint32
+
+const (
+	CursorModeVisible  CursorMode = 0
+	CursorModeHidden   CursorMode = 1
+	CursorModeCaptured CursorMode = 2
+)
+
 type EngineFlag uint32
 
 const (
-	EngineFlagSetupAfterReload EngineFlag = 2
 	EngineFlagHotReload        EngineFlag = 1
+	EngineFlagSetupAfterReload EngineFlag = 2
 	EngineFlagLogging          EngineFlag = 4
 )
 
+type Flip uint32
+
+const (
+	FlipX Flip = 1
+	FlipY Flip = 2
+)
+
+type GamepadAxis uint32
+
+const (
+	GamepadAxisLeftX        GamepadAxis = 0
+	GamepadAxisLeftY        GamepadAxis = 1
+	GamepadAxisRightX       GamepadAxis = 2
+	GamepadAxisRightY       GamepadAxis = 3
+	GamepadAxisLeftTrigger  GamepadAxis = 4
+	GamepadAxisRightTrigger GamepadAxis = 5
+)
+
 type InputEvent uint32
 
 const (
-	InputEventBackspace   InputEvent = 5
-	InputEventEnter       InputEvent = 3
-	InputEventEscape      InputEvent = 2
-	InputEventMouseLeft   InputEvent = 8
-	InputEventMouseMiddle InputEvent = 9
-	InputEventMouseRight  InputEvent = 10
-	InputEventSpace       InputEvent = 4
+	InputEventEscape              InputEvent = 2
+	InputEventEnter               InputEvent = 3
+	InputEventSpace               InputEvent = 4
+	InputEventBackspace           InputEvent = 5
+	InputEventA                   InputEvent = 6
+	InputEventB                   InputEvent = 7
+	InputEventC                   InputEvent = 8
+	InputEventD                   InputEvent = 9
+	InputEventE                   InputEvent = 10
+	InputEventF                   InputEvent = 11
+	InputEventG                   InputEvent = 12
+	InputEventH                   InputEvent = 13
+	InputEventI                   InputEvent = 14
+	InputEventJ                   InputEvent = 15
+	InputEventK                   InputEvent = 16
+	InputEventL                   InputEvent = 17
+	InputEventM                   InputEvent = 18
+	InputEventN                   InputEvent = 19
+	InputEventO                   InputEvent = 20
+	InputEventP                   InputEvent = 21
+	InputEventQ                   InputEvent = 22
+	InputEventR                   InputEvent = 23
+	InputEventS                   InputEvent = 24
+	InputEventT                   InputEvent = 25
+	InputEventU                   InputEvent = 26
+	InputEventV                   InputEvent = 27
+	InputEventW                   InputEvent = 28
+	InputEventX                   InputEvent = 29
+	InputEventY                   InputEvent = 30
+	InputEventZ                   InputEvent = 31
+	InputEventAltLeft             InputEvent = 32
+	InputEventAltRight            InputEvent = 33
+	InputEventArrowDown           InputEvent = 34
+	InputEventArrowLeft           InputEvent = 35
+	InputEventArrowRight          InputEvent = 36
+	InputEventArrowUp             InputEvent = 37
+	InputEventBackquote           InputEvent = 38
+	InputEventBackslash           InputEvent = 39
+	InputEventBracketLeft         InputEvent = 40
+	InputEventBracketRight        InputEvent = 41
+	InputEventCapsLock            InputEvent = 42
+	InputEventComma               InputEvent = 43
+	InputEventContextMenu         InputEvent = 44
+	InputEventControlLeft         InputEvent = 45
+	InputEventControlRight        InputEvent = 46
+	InputEventDelete              InputEvent = 47
+	InputEventDigit0              InputEvent = 48
+	InputEventDigit1              InputEvent = 49
+	InputEventDigit2              InputEvent = 50
+	InputEventDigit3              InputEvent = 51
+	InputEventDigit4              InputEvent = 52
+	InputEventDigit5              InputEvent = 53
+	InputEventDigit6              InputEvent = 54
+	InputEventDigit7              InputEvent = 55
+	InputEventDigit8              InputEvent = 56
+	InputEventDigit9              InputEvent = 57
+	InputEventEnd                 InputEvent = 58
+	InputEventEqual               InputEvent = 59
+	InputEventF1                  InputEvent = 60
+	InputEventF2                  InputEvent = 61
+	InputEventF3                  InputEvent = 62
+	InputEventF4                  InputEvent = 63
+	InputEventF5                  InputEvent = 64
+	InputEventF6                  InputEvent = 65
+	InputEventF7                  InputEvent = 66
+	InputEventF8                  InputEvent = 67
+	InputEventF9                  InputEvent = 68
+	InputEventF10                 InputEvent = 69
+	InputEventF11                 InputEvent = 70
+	InputEventF12                 InputEvent = 71
+	InputEventHome                InputEvent = 72
+	InputEventInsert              InputEvent = 73
+	InputEventMetaLeft            InputEvent = 74
+	InputEventMetaRight           InputEvent = 75
+	InputEventMinus               InputEvent = 76
+	InputEventNumLock             InputEvent = 77
+	InputEventNumpad0             InputEvent = 78
+	InputEventNumpad1             InputEvent = 79
+	InputEventNumpad2             InputEvent = 80
+	InputEventNumpad3             InputEvent = 81
+	InputEventNumpad4             InputEvent = 82
+	InputEventNumpad5             InputEvent = 83
+	InputEventNumpad6             InputEvent = 84
+	InputEventNumpad7             InputEvent = 85
+	InputEventNumpad8             InputEvent = 86
+	InputEventNumpad9             InputEvent = 87
+	InputEventNumpadAdd           InputEvent = 88
+	InputEventNumpadDecimal       InputEvent = 89
+	InputEventNumpadDivide        InputEvent = 90
+	InputEventNumpadEnter         InputEvent = 91
+	InputEventNumpadEqual         InputEvent = 92
+	InputEventNumpadMultiply      InputEvent = 93
+	InputEventNumpadSubtract      InputEvent = 94
+	InputEventPageDown            InputEvent = 95
+	InputEventPageUp              InputEvent = 96
+	InputEventPause               InputEvent = 97
+	InputEventPeriod              InputEvent = 98
+	InputEventPrintScreen         InputEvent = 99
+	InputEventQuote               InputEvent = 100
+	InputEventScrollLock          InputEvent = 101
+	InputEventSemicolon           InputEvent = 102
+	InputEventShiftLeft           InputEvent = 103
+	InputEventShiftRight          InputEvent = 104
+	InputEventSlash               InputEvent = 105
+	InputEventTab                 InputEvent = 106
+	InputEventAlt                 InputEvent = 107
+	InputEventControl             InputEvent = 108
+	InputEventShift               InputEvent = 109
+	InputEventMeta                InputEvent = 110
+	InputEventMouseLeft           InputEvent = 113
+	InputEventMouseMiddle         InputEvent = 114
+	InputEventMouseRight          InputEvent = 115
+	InputEventGamepadA            InputEvent = 118
+	InputEventGamepadB            InputEvent = 119
+	InputEventGamepadX            InputEvent = 120
+	InputEventGamepadY            InputEvent = 121
+	InputEventGamepadLeftBumper   InputEvent = 122
+	InputEventGamepadRightBumper  InputEvent = 123
+	InputEventGamepadLeftTrigger  InputEvent = 124
+	InputEventGamepadRightTrigger InputEvent = 125
+	InputEventGamepadBack         InputEvent = 126
+	InputEventGamepadStart        InputEvent = 127
+	InputEventGamepadGuide        InputEvent = 128
+	InputEventGamepadLeftStick    InputEvent = 129
+	InputEventGamepadRightStick   InputEvent = 130
+	InputEventGamepadDpadUp       InputEvent = 131
+	InputEventGamepadDpadDown     InputEvent = 132
+	InputEventGamepadDpadLeft     InputEvent = 133
+	InputEventGamepadDpadRight    InputEvent = 134
+)
+
+type Modifier uint32
+
+const (
+	ModifierControl Modifier = 1
+	ModifierShift   Modifier = 2
+	ModifierAlt     Modifier = 4
+	ModifierMeta    Modifier = 8
 )
 
+type Sound uint32
+
 type Texture uint32
 
+type Voice uint32
+
 // Structs
 
 type Color struct {
@@ -34,6 +208,32 @@ type Color struct {
 	C4 float32
 }
 
+type DrawCommand struct {
+	D1  Texture
+	D2  float32
+	D3  float32
+	D4  float32
+	D5  float32
+	D6  float32
+	D7  float32
+	D8  float32
+	D9  Flip
+	D10 Color
+	D11 Rect
+}
+
+type Rect struct {
+	R1 float32
+	R2 float32
+	R3 float32
+	R4 float32
+}
+
+type TextureInfo struct {
+	T1 int32
+	T2 int32
+}
+
 // Config Api
 //
 //go:export ConfigGetEngineFlags
@@ -53,6 +253,12 @@ func PlatformFps() float32
 //go:export PlatformLog
 func PlatformLog(string)
 
+//go:export PlatformRandom
+func PlatformRandom() float32
+
+//go:export PlatformReloadCount
+func PlatformReloadCount() int32
+
 //go:export PlatformSetScreenSize
 func PlatformSetScreenSize(int32, int32)
 
@@ -64,20 +270,131 @@ func PlatformTps() float32
 
 // Input Api
 //
+//go:export InputActionHeld
+func InputActionHeld(string) bool
+
+//go:export InputActionJustPressed
+func InputActionJustPressed(string) bool
+
+//go:export InputActionJustReleased
+func InputActionJustReleased(string) bool
+
+//go:export InputAxisValue
+func InputAxisValue(string) float32
+
+//go:export InputBindAction
+func InputBindAction(string, InputEvent)
+
+//go:export InputBindAxis
+func InputBindAxis(string, InputEvent, InputEvent)
+
+//go:export InputBindGamepadAxis
+func InputBindGamepadAxis(string, GamepadAxis)
+
+//go:export InputClearBindings
+func InputClearBindings(string)
+
+//go:export InputCursorDeltaX
+func InputCursorDeltaX() float32
+
+//go:export InputCursorDeltaY
+func InputCursorDeltaY() float32
+
 //go:export InputCursorX
 func InputCursorX() float32
 
 //go:export InputCursorY
 func InputCursorY() float32
 
-//go:export InputDown
-func InputDown(InputEvent) bool
+//go:export InputGamepadAxis
+func InputGamepadAxis(int32, GamepadAxis) float32
+
+//go:export InputGamepadConnected
+func InputGamepadConnected(int32) bool
+
+//go:export InputGamepadHeld
+func InputGamepadHeld(int32, InputEvent) bool
+
+//go:export InputGamepadHeldDuration
+func InputGamepadHeldDuration(int32, InputEvent) int32
+
+//go:export InputGamepadJustPressed
+func InputGamepadJustPressed(int32, InputEvent) bool
+
+//go:export InputGamepadJustReleased
+func InputGamepadJustReleased(int32, InputEvent) bool
+
+//go:export InputGetCursorMode
+func InputGetCursorMode() CursorMode
+
+//go:export InputHeld
+func InputHeld(InputEvent) bool
+
+//go:export InputHeldDuration
+func InputHeldDuration(InputEvent) int32
+
+//go:export InputJustPressed
+func InputJustPressed(InputEvent) bool
+
+//go:export InputJustReleased
+func InputJustReleased(InputEvent) bool
+
+//go:export InputLoadBindings
+func InputLoadBindings(string) bool
+
+//go:export InputModifierDown
+func InputModifierDown(Modifier) bool
+
+//go:export InputRepeated
+func InputRepeated(InputEvent) bool
+
+//go:export InputSaveBindings
+func InputSaveBindings(string) bool
+
+//go:export InputSetCursorMode
+func InputSetCursorMode(CursorMode)
+
+//go:export InputSetGamepadDeadzone
+func InputSetGamepadDeadzone(float32)
+
+//go:export InputSetTouchAsMouse
+func InputSetTouchAsMouse(bool)
+
+//go:export InputTextInput
+func _InputTextInput(*engineSlice)
+
+func InputTextInput() string {
+	var r0 engineSlice
+	_InputTextInput(&r0)
+	return receiveString(r0)
+}
+
+//go:export InputTouchCount
+func InputTouchCount() int32
+
+//go:export InputTouchDuration
+func InputTouchDuration(int32) int32
+
+//go:export InputTouchID
+func InputTouchID(int32) int32
+
+//go:export InputTouchJustBegan
+func InputTouchJustBegan(int32) bool
+
+//go:export InputTouchJustEnded
+func InputTouchJustEnded(int32) bool
+
+//go:export InputTouchX
+func InputTouchX(int32) float32
+
+//go:export InputTouchY
+func InputTouchY(int32) float32
 
-//go:export InputPressed
-func InputPressed(InputEvent) bool
+//go:export InputWheelX
+func InputWheelX() float32
 
-//go:export InputUp
-func InputUp(InputEvent) bool
+//go:export InputWheelY
+func InputWheelY() float32
 
 // Graphics Api
 //
@@ -90,9 +407,22 @@ func GraphicsClear(float32, float32, float32, float32)
 //go:export GraphicsRectangle
 func GraphicsRectangle(float32, float32, float32, float32, float32, float32, float32, float32, bool)
 
+//go:export GraphicsScreenshot
+func GraphicsScreenshot(string) bool
+
 //go:export GraphicsSetTargetSize
 func GraphicsSetTargetSize(int32, int32)
 
+//go:export GraphicsSprite
+func GraphicsSprite(Texture, float32, float32, float32, float32, float32, float32, float32, float32, float32, float32, float32, Flip, float32, float32, float32, float32)
+
+//go:export GraphicsSubmit
+func _GraphicsSubmit(*DrawCommand, uint32)
+
+func GraphicsSubmit(a0 []DrawCommand) {
+	_GraphicsSubmit(unsafe.SliceData(a0), uint32(len(a0)))
+}
+
 //go:export GraphicsText
 func GraphicsText(string, float32, float32)
 
@@ -102,7 +432,154 @@ func GraphicsTexture(Texture, float32, float32)
 //go:export GraphicsTextureEx
 func GraphicsTextureEx(Texture, float32, float32, float32, float32, float32, float32, float32, float32, float32)
 
+//go:export GraphicsTextureRegion
+func GraphicsTextureRegion(Texture, float32, float32, float32, float32, float32, float32)
+
+//go:export GraphicsTextureRegionEx
+func GraphicsTextureRegionEx(Texture, float32, float32, float32, float32, float32, float32, float32, float32, float32, float32, float32, float32, float32)
+
 // Asset Api
 //
+//go:export AssetAtlasFrame
+func AssetAtlasFrame(Atlas, string) Texture
+
+//go:export AssetAtlasRegion
+func _AssetAtlasRegion(Atlas, string, *Rect)
+
+func AssetAtlasRegion(a0_0 Atlas, a1_0 string) Rect {
+	var r0 Rect
+	_AssetAtlasRegion(a0_0, a1_0, &r0)
+	return r0
+}
+
+//go:export AssetAtlasTexture
+func AssetAtlasTexture(Atlas) Texture
+
+//go:export AssetAtlasTrim
+func _AssetAtlasTrim(Atlas, string, *Rect)
+
+func AssetAtlasTrim(a0_0 Atlas, a1_0 string) Rect {
+	var r0 Rect
+	_AssetAtlasTrim(a0_0, a1_0, &r0)
+	return r0
+}
+
+//go:export AssetLoadAtlas
+func AssetLoadAtlas(string) Atlas
+
 //go:export AssetLoadTexture
 func AssetLoadTexture(string) Texture
+
+//go:export AssetReadFile
+func _AssetReadFile(string, *engineSlice)
+
+func AssetReadFile(a0_0 string) []byte {
+	var r0 engineSlice
+	_AssetReadFile(a0_0, &r0)
+	return receiveSlice[byte](r0)
+}
+
+//go:export AssetTextureInfo
+func _AssetTextureInfo(Texture, *TextureInfo)
+
+func AssetTextureInfo(a0_0 Texture) TextureInfo {
+	var r0 TextureInfo
+	_AssetTextureInfo(a0_0, &r0)
+	return r0
+}
+
+// Audio Api
+//
+//go:export AudioLoadMusic
+func AudioLoadMusic(string) Sound
+
+//go:export AudioLoadSound
+func AudioLoadSound(string) Sound
+
+//go:export AudioPause
+func AudioPause(Voice)
+
+//go:export AudioPlay
+func AudioPlay(Sound, bool) Voice
+
+//go:export AudioPlaying
+func AudioPlaying(Voice) bool
+
+//go:export AudioPushSamples
+func _AudioPushSamples(*float32, uint32) int32
+
+func AudioPushSamples(a0 []float32) int32 {
+	r0 := _AudioPushSamples(unsafe.SliceData(a0), uint32(len(a0)))
+	return r0
+}
+
+//go:export AudioQueuedSamples
+func AudioQueuedSamples() int32
+
+//go:export AudioResume
+func AudioResume(Voice)
+
+//go:export AudioSetBusDucking
+func AudioSetBusDucking(Bus, Bus, float32)
+
+//go:export AudioSetBusLowPass
+func AudioSetBusLowPass(Bus, float32)
+
+//go:export AudioSetBusMuted
+func AudioSetBusMuted(Bus, bool)
+
+//go:export AudioSetBusReverb
+func AudioSetBusReverb(Bus, float32)
+
+//go:export AudioSetBusVolume
+func AudioSetBusVolume(Bus, float32)
+
+//go:export AudioSetPan
+func AudioSetPan(Voice, float32)
+
+//go:export AudioSetPitch
+func AudioSetPitch(Voice, float32)
+
+//go:export AudioSetVoiceBus
+func AudioSetVoiceBus(Voice, Bus)
+
+//go:export AudioSetVolume
+func AudioSetVolume(Voice, float32)
+
+//go:export AudioStartStream
+func AudioStartStream() Voice
+
+//go:export AudioStop
+func AudioStop(Voice)
+
+// Memory given to the engine through alloc.
+// Entries are removed once the value the engine wrote to them has been received.
+var allocations = map[uintptr][]byte{}
+
+//go:export alloc
+func alloc(size uint32) unsafe.Pointer {
+	buf := make([]byte, size)
+	ptr := unsafe.Pointer(unsafe.SliceData(buf))
+	allocations[uintptr(ptr)] = buf
+	return ptr
+}
+
+// engineSlice is the location and length of a value the engine allocated with alloc
+type engineSlice struct {
+	ptr, len uint32
+}
+
+func receiveSlice[T any](s engineSlice) []T {
+	if s.len == 0 {
+		return nil
+	}
+
+	buf := allocations[uintptr(s.ptr)]
+	delete(allocations, uintptr(s.ptr))
+
+	return unsafe.Slice((*T)(unsafe.Pointer(unsafe.SliceData(buf))), s.len)
+}
+
+func receiveString(s engineSlice) string {
+	return string(receiveSlice[byte](s))
+}
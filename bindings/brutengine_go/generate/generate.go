@@ -2,10 +2,13 @@ package main
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"go/format"
 	"os"
+	"slices"
+	"strings"
 )
 
 // This file generates golang bindings
@@ -38,12 +41,42 @@ var api Api
 var apiTypeMap = map[string]string{
 	"i32":    "int32",
 	"u32":    "uint32",
+	"u8":     "byte",
 	"f32":    "float32",
 	"bool":   "bool",
 	"string": "string",
 }
 
+// sliceElemType returns the go element type of a slice api type
+func sliceElemType(t string) (string, bool) {
+	elem, isSlice := strings.CutPrefix(t, "[]")
+	if !isSlice {
+		return "", false
+	}
+
+	if _, isStruct := api.Structs[elem]; isStruct {
+		return elem, true
+	}
+
+	if _, isEnum := api.Enums[elem]; isEnum {
+		return elem, true
+	}
+
+	goT, ok := apiTypeMap[elem]
+	if !ok {
+		panic("unknown type: " + t)
+	}
+
+	return goT, true
+}
+
 func printType(buf *bytes.Buffer, t string) {
+	// Slices are passed as a pointer/length pair
+	if elem, isSlice := sliceElemType(t); isSlice {
+		fmt.Fprintf(buf, "*%s, uint32", elem)
+		return
+	}
+
 	if st, isStruct := api.Structs[t]; isStruct {
 		alias, hasAlias := apiTypeMap[t]
 		if hasAlias {
@@ -74,6 +107,157 @@ func printType(buf *bytes.Buffer, t string) {
 	}
 }
 
+// returnedByPointer reports if the engine writes values of type t to memory rather than returning them
+func returnedByPointer(t string) bool {
+	_, isSlice := sliceElemType(t)
+	_, isStruct := api.Structs[t]
+	return isSlice || isStruct
+}
+
+// needsWrapper reports if fn takes or returns values that can't be passed to the engine directly
+func needsWrapper(fn Function) bool {
+	for _, arg := range fn.Args {
+		if _, isSlice := sliceElemType(arg); isSlice {
+			return true
+		}
+	}
+
+	for _, ret := range fn.Rets {
+		if returnedByPointer(ret) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// printWrapper writes a function that accepts and returns go values, calling the import with
+// pointer/length pairs for slices and pointers for values the engine returns through memory.
+func printWrapper(buf *bytes.Buffer, name, importName string, fn Function) {
+	var (
+		params  bytes.Buffer
+		call    bytes.Buffer
+		results bytes.Buffer
+		locals  bytes.Buffer
+		assign  bytes.Buffer
+		returns bytes.Buffer
+	)
+
+	sep := func(b *bytes.Buffer) {
+		if b.Len() > 0 {
+			b.WriteString(", ")
+		}
+	}
+
+	for i, arg := range fn.Args {
+		if elem, isSlice := sliceElemType(arg); isSlice {
+			sep(&params)
+			sep(&call)
+			fmt.Fprintf(&params, "a%d []%s", i, elem)
+			fmt.Fprintf(&call, "unsafe.SliceData(a%[1]d), uint32(len(a%[1]d))", i)
+			continue
+		}
+
+		// Other arguments keep the same (possibly flattened) types as the import
+		var types bytes.Buffer
+		printType(&types, arg)
+
+		for ti, t := range strings.Split(types.String(), ", ") {
+			sep(&params)
+			sep(&call)
+			fmt.Fprintf(&params, "a%d_%d %s", i, ti, t)
+			fmt.Fprintf(&call, "a%d_%d", i, ti)
+		}
+	}
+
+	for i, ret := range fn.Rets {
+		sep(&results)
+		sep(&returns)
+
+		elem, isSlice := sliceElemType(ret)
+
+		switch {
+		case ret == "string":
+			results.WriteString("string")
+			fmt.Fprintf(&locals, "\tvar r%d engineSlice\n", i)
+			fmt.Fprintf(&returns, "receiveString(r%d)", i)
+		case isSlice:
+			fmt.Fprintf(&results, "[]%s", elem)
+			fmt.Fprintf(&locals, "\tvar r%d engineSlice\n", i)
+			fmt.Fprintf(&returns, "receiveSlice[%s](r%d)", elem, i)
+		case returnedByPointer(ret):
+			results.WriteString(ret)
+			fmt.Fprintf(&locals, "\tvar r%d %s\n", i, ret)
+			fmt.Fprintf(&returns, "r%d", i)
+		default:
+			printType(&results, ret)
+			sep(&assign)
+			fmt.Fprintf(&assign, "r%d", i)
+			fmt.Fprintf(&returns, "r%d", i)
+			continue
+		}
+
+		sep(&call)
+		fmt.Fprintf(&call, "&r%d", i)
+	}
+
+	fmt.Fprintf(buf, "\nfunc %s(%s) ", name, params.String())
+	if results.Len() > 0 {
+		fmt.Fprintf(buf, "(%s) ", results.String())
+	}
+
+	buf.WriteString("{\n")
+	buf.Write(locals.Bytes())
+	buf.WriteByte('\t')
+
+	if assign.Len() > 0 {
+		fmt.Fprintf(buf, "%s := ", assign.String())
+	}
+
+	fmt.Fprintf(buf, "%s(%s)\n", importName, call.String())
+
+	if returns.Len() > 0 {
+		fmt.Fprintf(buf, "\treturn %s\n", returns.String())
+	}
+
+	buf.WriteString("}\n\n")
+}
+
+// allocHelpers are written once when any function returns values through memory
+const allocHelpers = `
+// Memory given to the engine through alloc.
+// Entries are removed once the value the engine wrote to them has been received.
+var allocations = map[uintptr][]byte{}
+
+//go:export alloc
+func alloc(size uint32) unsafe.Pointer {
+	buf := make([]byte, size)
+	ptr := unsafe.Pointer(unsafe.SliceData(buf))
+	allocations[uintptr(ptr)] = buf
+	return ptr
+}
+
+// engineSlice is the location and length of a value the engine allocated with alloc
+type engineSlice struct {
+	ptr, len uint32
+}
+
+func receiveSlice[T any](s engineSlice) []T {
+	if s.len == 0 {
+		return nil
+	}
+
+	buf := allocations[uintptr(s.ptr)]
+	delete(allocations, uintptr(s.ptr))
+
+	return unsafe.Slice((*T)(unsafe.Pointer(unsafe.SliceData(buf))), s.len)
+}
+
+func receiveString(s engineSlice) string {
+	return string(receiveSlice[byte](s))
+}
+`
+
 func main() {
 	fmt.Println("generating bindings...")
 
@@ -87,13 +271,19 @@ func main() {
 		panic(err)
 	}
 
-	var buf bytes.Buffer
+	var (
+		buf          bytes.Buffer
+		usesUnsafe   bool
+		usesPointers bool
+	)
+
 	buf.WriteString("// Code generated by 'go run generate.go'; DO NOT EDIT.\n")
 	buf.WriteString("package brutengine_go\n")
 
 	// Generate enums
 	buf.WriteString("// Enums\n\n")
-	for name, e := range api.Enums {
+	for _, name := range sortedKeys(api.Enums) {
+		e := api.Enums[name]
 		goT := apiTypeMap[e.Type]
 		apiTypeMap[name] = goT
 
@@ -102,7 +292,8 @@ func main() {
 		if len(e.Values) > 0 {
 			buf.WriteString("const (\n")
 
-			for field, value := range e.Values {
+			for _, field := range sortedValues(e.Values) {
+				value := e.Values[field]
 				fmt.Fprintf(&buf, "\t%s%s %s = %d\n", name, field, name, value)
 			}
 
@@ -114,7 +305,8 @@ func main() {
 
 	// Generate structs
 	fmt.Fprintf(&buf, "// Structs\n\n")
-	for name, s := range api.Structs {
+	for _, name := range sortedKeys(api.Structs) {
+		s := api.Structs[name]
 		if name == "string" {
 			continue
 		}
@@ -124,7 +316,14 @@ func main() {
 		firstLetter := name[0]
 		for i, t := range s {
 			fmt.Fprintf(&buf, "%c%d ", firstLetter, i+1)
-			printType(&buf, t)
+
+			// Nested structs are stored as is rather than flattened
+			if _, isStruct := api.Structs[t]; isStruct {
+				buf.WriteString(t)
+			} else {
+				printType(&buf, t)
+			}
+
 			buf.WriteByte('\n')
 		}
 
@@ -135,8 +334,15 @@ func main() {
 		fmt.Fprintf(&buf, "// %s Api\n", export.Namespace)
 
 		for _, fn := range export.Functions {
+			wrapped := needsWrapper(fn)
+
+			importName := export.Namespace + fn.Name
+			if wrapped {
+				importName = "_" + importName
+			}
+
 			fmt.Fprintf(&buf, "//go:export %s%s\n", export.Namespace, fn.Name)
-			fmt.Fprintf(&buf, "func %s%s(", export.Namespace, fn.Name)
+			fmt.Fprintf(&buf, "func %s(", importName)
 
 			// Function arguments
 			for i, arg := range fn.Args {
@@ -146,15 +352,35 @@ func main() {
 				}
 			}
 
+			// Pointers the engine writes return values to
+			var stackRets []string
+			for _, ret := range fn.Rets {
+				if !returnedByPointer(ret) {
+					stackRets = append(stackRets, ret)
+					continue
+				}
+
+				usesPointers = true
+				if buf.Bytes()[buf.Len()-1] != '(' {
+					buf.WriteString(", ")
+				}
+
+				if _, isStruct := api.Structs[ret]; isStruct && ret != "string" {
+					fmt.Fprintf(&buf, "*%s", ret)
+				} else {
+					buf.WriteString("*engineSlice")
+				}
+			}
+
 			buf.WriteString(")")
 
 			// Function returns
-			if len(fn.Rets) > 0 {
+			if len(stackRets) > 0 {
 				buf.WriteString(" (")
 
-				for i, ret := range fn.Rets {
+				for i, ret := range stackRets {
 					printType(&buf, ret)
-					if i < len(fn.Rets)-1 {
+					if i < len(stackRets)-1 {
 						buf.WriteString(", ")
 					}
 				}
@@ -163,12 +389,26 @@ func main() {
 			}
 
 			buf.WriteByte('\n')
+
+			if wrapped {
+				usesUnsafe = true
+				printWrapper(&buf, export.Namespace+fn.Name, importName, fn)
+			}
 		}
 
 		buf.WriteString("\n\n")
 	}
 
-	formatted, err := format.Source(buf.Bytes())
+	if usesPointers {
+		buf.WriteString(allocHelpers)
+	}
+
+	src = buf.Bytes()
+	if usesUnsafe {
+		src = bytes.Replace(src, []byte("package brutengine_go\n"), []byte("package brutengine_go\n\nimport \"unsafe\"\n"), 1)
+	}
+
+	formatted, err := format.Source(src)
 	if err != nil {
 		panic(err)
 	}
@@ -180,3 +420,24 @@ func main() {
 
 	fmt.Println("done!")
 }
+
+// sortedKeys returns the keys of m in order so the bindings don't change between runs
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+	return keys
+}
+
+// sortedValues returns the names of an enum's values ordered by value
+func sortedValues(values map[string]int) []string {
+	names := sortedKeys(values)
+	slices.SortStableFunc(names, func(a, b string) int {
+		return cmp.Compare(values[a], values[b])
+	})
+
+	return names
+}
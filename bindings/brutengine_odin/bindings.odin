@@ -1,26 +1,190 @@
 // Code generated by 'odin run generate.odin -file'; DO NOT EDIT.
 package brutengine_odin
 
+import "core:runtime"
+
 // Enums & Types
 
-Texture :: u32
+Atlas :: u32
+
+Bus :: enum u32 {
+	Master = 0,
+	Music = 1,
+	Sfx = 2,
+	Voice = 3,
+}
+
+CursorMode :: enum u32 {
+	Captured = 2,
+	Hidden = 1,
+	Visible = 0,
+}
 
 EngineFlag :: enum u32 {
-	SetupAfterReload = 2,
 	HotReload = 1,
 	Logging = 4,
+	SetupAfterReload = 2,
+}
+
+Flip :: enum u32 {
+	X = 1,
+	Y = 2,
+}
+
+GamepadAxis :: enum u32 {
+	LeftTrigger = 4,
+	LeftX = 0,
+	LeftY = 1,
+	RightTrigger = 5,
+	RightX = 2,
+	RightY = 3,
 }
 
 InputEvent :: enum u32 {
-	MouseRight = 10,
+	A = 6,
+	Alt = 107,
+	AltLeft = 32,
+	AltRight = 33,
+	ArrowDown = 34,
+	ArrowLeft = 35,
+	ArrowRight = 36,
+	ArrowUp = 37,
+	B = 7,
+	Backquote = 38,
+	Backslash = 39,
 	Backspace = 5,
-	MouseLeft = 8,
-	Escape = 2,
+	BracketLeft = 40,
+	BracketRight = 41,
+	C = 8,
+	CapsLock = 42,
+	Comma = 43,
+	ContextMenu = 44,
+	Control = 108,
+	ControlLeft = 45,
+	ControlRight = 46,
+	D = 9,
+	Delete = 47,
+	Digit0 = 48,
+	Digit1 = 49,
+	Digit2 = 50,
+	Digit3 = 51,
+	Digit4 = 52,
+	Digit5 = 53,
+	Digit6 = 54,
+	Digit7 = 55,
+	Digit8 = 56,
+	Digit9 = 57,
+	E = 10,
+	End = 58,
 	Enter = 3,
-	MouseMiddle = 9,
+	Equal = 59,
+	Escape = 2,
+	F = 11,
+	F1 = 60,
+	F10 = 69,
+	F11 = 70,
+	F12 = 71,
+	F2 = 61,
+	F3 = 62,
+	F4 = 63,
+	F5 = 64,
+	F6 = 65,
+	F7 = 66,
+	F8 = 67,
+	F9 = 68,
+	G = 12,
+	GamepadA = 118,
+	GamepadB = 119,
+	GamepadBack = 126,
+	GamepadDpadDown = 132,
+	GamepadDpadLeft = 133,
+	GamepadDpadRight = 134,
+	GamepadDpadUp = 131,
+	GamepadGuide = 128,
+	GamepadLeftBumper = 122,
+	GamepadLeftStick = 129,
+	GamepadLeftTrigger = 124,
+	GamepadRightBumper = 123,
+	GamepadRightStick = 130,
+	GamepadRightTrigger = 125,
+	GamepadStart = 127,
+	GamepadX = 120,
+	GamepadY = 121,
+	H = 13,
+	Home = 72,
+	I = 14,
+	Insert = 73,
+	J = 15,
+	K = 16,
+	L = 17,
+	M = 18,
+	Meta = 110,
+	MetaLeft = 74,
+	MetaRight = 75,
+	Minus = 76,
+	MouseLeft = 113,
+	MouseMiddle = 114,
+	MouseRight = 115,
+	N = 19,
+	NumLock = 77,
+	Numpad0 = 78,
+	Numpad1 = 79,
+	Numpad2 = 80,
+	Numpad3 = 81,
+	Numpad4 = 82,
+	Numpad5 = 83,
+	Numpad6 = 84,
+	Numpad7 = 85,
+	Numpad8 = 86,
+	Numpad9 = 87,
+	NumpadAdd = 88,
+	NumpadDecimal = 89,
+	NumpadDivide = 90,
+	NumpadEnter = 91,
+	NumpadEqual = 92,
+	NumpadMultiply = 93,
+	NumpadSubtract = 94,
+	O = 20,
+	P = 21,
+	PageDown = 95,
+	PageUp = 96,
+	Pause = 97,
+	Period = 98,
+	PrintScreen = 99,
+	Q = 22,
+	Quote = 100,
+	R = 23,
+	S = 24,
+	ScrollLock = 101,
+	Semicolon = 102,
+	Shift = 109,
+	ShiftLeft = 103,
+	ShiftRight = 104,
+	Slash = 105,
 	Space = 4,
+	T = 25,
+	Tab = 106,
+	U = 26,
+	V = 27,
+	W = 28,
+	X = 29,
+	Y = 30,
+	Z = 31,
+}
+
+Modifier :: enum u32 {
+	Alt = 4,
+	Control = 1,
+	Meta = 8,
+	Shift = 2,
 }
 
+Sound :: u32
+
+Texture :: u32
+
+Voice :: u32
+
 // Structs
 
 Color :: struct {
@@ -30,6 +194,32 @@ Color :: struct {
 	c4: f32,
 }
 
+DrawCommand :: struct {
+	d1: Texture,
+	d2: f32,
+	d3: f32,
+	d4: f32,
+	d5: f32,
+	d6: f32,
+	d7: f32,
+	d8: f32,
+	d9: Flip,
+	d10: Color,
+	d11: Rect,
+}
+
+Rect :: struct {
+	r1: f32,
+	r2: f32,
+	r3: f32,
+	r4: f32,
+}
+
+TextureInfo :: struct {
+	t1: i32,
+	t2: i32,
+}
+
 // Functions
 
 foreign import env "env"
@@ -42,23 +232,131 @@ foreign env {
 	PlatformExit :: proc()  ---
 	PlatformFps :: proc() -> f32 ---
 	PlatformLog :: proc(string)  ---
+	PlatformRandom :: proc() -> f32 ---
+	PlatformReloadCount :: proc() -> i32 ---
 	PlatformSetScreenSize :: proc(i32, i32)  ---
 	PlatformSetTitle :: proc(string)  ---
 	PlatformTps :: proc() -> f32 ---
 
+	InputActionHeld :: proc(string) -> bool ---
+	InputActionJustPressed :: proc(string) -> bool ---
+	InputActionJustReleased :: proc(string) -> bool ---
+	InputAxisValue :: proc(string) -> f32 ---
+	InputBindAction :: proc(string, InputEvent)  ---
+	InputBindAxis :: proc(string, InputEvent, InputEvent)  ---
+	InputBindGamepadAxis :: proc(string, GamepadAxis)  ---
+	InputClearBindings :: proc(string)  ---
+	InputCursorDeltaX :: proc() -> f32 ---
+	InputCursorDeltaY :: proc() -> f32 ---
 	InputCursorX :: proc() -> f32 ---
 	InputCursorY :: proc() -> f32 ---
-	InputDown :: proc(InputEvent) -> bool ---
-	InputPressed :: proc(InputEvent) -> bool ---
-	InputUp :: proc(InputEvent) -> bool ---
+	InputGamepadAxis :: proc(i32, GamepadAxis) -> f32 ---
+	InputGamepadConnected :: proc(i32) -> bool ---
+	InputGamepadHeld :: proc(i32, InputEvent) -> bool ---
+	InputGamepadHeldDuration :: proc(i32, InputEvent) -> i32 ---
+	InputGamepadJustPressed :: proc(i32, InputEvent) -> bool ---
+	InputGamepadJustReleased :: proc(i32, InputEvent) -> bool ---
+	InputGetCursorMode :: proc() -> CursorMode ---
+	InputHeld :: proc(InputEvent) -> bool ---
+	InputHeldDuration :: proc(InputEvent) -> i32 ---
+	InputJustPressed :: proc(InputEvent) -> bool ---
+	InputJustReleased :: proc(InputEvent) -> bool ---
+	InputLoadBindings :: proc(string) -> bool ---
+	InputModifierDown :: proc(Modifier) -> bool ---
+	InputRepeated :: proc(InputEvent) -> bool ---
+	InputSaveBindings :: proc(string) -> bool ---
+	InputSetCursorMode :: proc(CursorMode)  ---
+	InputSetGamepadDeadzone :: proc(f32)  ---
+	InputSetTouchAsMouse :: proc(bool)  ---
+	@(link_name="InputTextInput")
+	_InputTextInput :: proc(^string)  ---
+	InputTouchCount :: proc() -> i32 ---
+	InputTouchDuration :: proc(i32) -> i32 ---
+	InputTouchID :: proc(i32) -> i32 ---
+	InputTouchJustBegan :: proc(i32) -> bool ---
+	InputTouchJustEnded :: proc(i32) -> bool ---
+	InputTouchX :: proc(i32) -> f32 ---
+	InputTouchY :: proc(i32) -> f32 ---
+	InputWheelX :: proc() -> f32 ---
+	InputWheelY :: proc() -> f32 ---
 
 	GraphicsCircle :: proc(f32, f32, f32, Color, bool)  ---
 	GraphicsClear :: proc(Color)  ---
 	GraphicsRectangle :: proc(f32, f32, f32, f32, Color, bool)  ---
+	GraphicsScreenshot :: proc(string) -> bool ---
 	GraphicsSetTargetSize :: proc(i32, i32)  ---
+	GraphicsSprite :: proc(Texture, Rect, f32, f32, f32, f32, f32, f32, f32, Flip, Color)  ---
+	GraphicsSubmit :: proc([]DrawCommand)  ---
 	GraphicsText :: proc(string, f32, f32)  ---
 	GraphicsTexture :: proc(Texture, f32, f32)  ---
 	GraphicsTextureEx :: proc(Texture, f32, f32, f32, f32, f32, Color)  ---
+	GraphicsTextureRegion :: proc(Texture, Rect, f32, f32)  ---
+	GraphicsTextureRegionEx :: proc(Texture, Rect, f32, f32, f32, f32, f32, Color)  ---
 
+	AssetAtlasFrame :: proc(Atlas, string) -> Texture ---
+	@(link_name="AssetAtlasRegion")
+	_AssetAtlasRegion :: proc(Atlas, string, ^Rect)  ---
+	AssetAtlasTexture :: proc(Atlas) -> Texture ---
+	@(link_name="AssetAtlasTrim")
+	_AssetAtlasTrim :: proc(Atlas, string, ^Rect)  ---
+	AssetLoadAtlas :: proc(string) -> Atlas ---
 	AssetLoadTexture :: proc(string) -> Texture ---
+	@(link_name="AssetReadFile")
+	_AssetReadFile :: proc(string, ^[]u8)  ---
+	@(link_name="AssetTextureInfo")
+	_AssetTextureInfo :: proc(Texture, ^TextureInfo)  ---
+
+	AudioLoadMusic :: proc(string) -> Sound ---
+	AudioLoadSound :: proc(string) -> Sound ---
+	AudioPause :: proc(Voice)  ---
+	AudioPlay :: proc(Sound, bool) -> Voice ---
+	AudioPlaying :: proc(Voice) -> bool ---
+	AudioPushSamples :: proc([]f32) -> i32 ---
+	AudioQueuedSamples :: proc() -> i32 ---
+	AudioResume :: proc(Voice)  ---
+	AudioSetBusDucking :: proc(Bus, Bus, f32)  ---
+	AudioSetBusLowPass :: proc(Bus, f32)  ---
+	AudioSetBusMuted :: proc(Bus, bool)  ---
+	AudioSetBusReverb :: proc(Bus, f32)  ---
+	AudioSetBusVolume :: proc(Bus, f32)  ---
+	AudioSetPan :: proc(Voice, f32)  ---
+	AudioSetPitch :: proc(Voice, f32)  ---
+	AudioSetVoiceBus :: proc(Voice, Bus)  ---
+	AudioSetVolume :: proc(Voice, f32)  ---
+	AudioStartStream :: proc() -> Voice ---
+	AudioStop :: proc(Voice)  ---
+}
+
+InputTextInput :: proc "contextless" () -> (r0: string) {
+	_InputTextInput(&r0)
+	return
+}
+
+AssetAtlasRegion :: proc "contextless" (a0: Atlas, a1: string) -> (r0: Rect) {
+	_AssetAtlasRegion(a0, a1, &r0)
+	return
+}
+
+AssetAtlasTrim :: proc "contextless" (a0: Atlas, a1: string) -> (r0: Rect) {
+	_AssetAtlasTrim(a0, a1, &r0)
+	return
+}
+
+AssetReadFile :: proc "contextless" (a0: string) -> (r0: []u8) {
+	_AssetReadFile(a0, &r0)
+	return
+}
+
+AssetTextureInfo :: proc "contextless" (a0: Texture) -> (r0: TextureInfo) {
+	_AssetTextureInfo(a0, &r0)
+	return
+}
+
+// Called by the engine to allocate memory for the values it returns.
+// The memory is owned by the caller and should be freed with the context allocator.
+@(export)
+alloc :: proc "c" (size: u32) -> rawptr {
+	context = runtime.default_context()
+	ptr, _ := runtime.mem_alloc(int(size))
+	return ptr
 }
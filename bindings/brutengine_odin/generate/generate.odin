@@ -34,6 +34,20 @@ Function :: struct {
 
 api: Api
 
+// Strings, slices, and structs are returned by the engine through a pointer given after the arguments
+is_by_pointer :: proc(t: string) -> bool {
+   if strings.has_prefix(t, "[]") do return true
+   _, is_struct := api.structs[t]
+   return is_struct
+}
+
+returns_by_pointer :: proc(fn: Function) -> bool {
+   for ret in fn.rets {
+      if is_by_pointer(ret) do return true
+   }
+   return false
+}
+
 main :: proc() {
 	file, ok := os.read_entire_file("../../engine_api.json")
 	if !ok {
@@ -49,6 +63,17 @@ main :: proc() {
 	strings.write_string(&buf, "// Code generated by 'odin run generate.odin -file'; DO NOT EDIT.\n")
 	strings.write_string(&buf, "package brutengine_odin\n\n")
 
+   uses_pointers := false
+   for exp in api.exports {
+      for fn in exp.functions {
+         if returns_by_pointer(fn) do uses_pointers = true
+      }
+   }
+
+   if uses_pointers {
+      strings.write_string(&buf, "import \"core:runtime\"\n\n")
+   }
+
 	// Generate enums
 	strings.write_string(&buf, "// Enums & Types\n\n")
 	for name, def in api.enums {
@@ -93,7 +118,13 @@ foreign env {
 
    for exp, i in api.exports {
       for fn in exp.functions {
-         fmt.sbprintf(&buf, "\t%s%s :: proc(", exp.namespace, fn.name)
+         wrapped := returns_by_pointer(fn)
+         if wrapped {
+            fmt.sbprintf(&buf, "\t@(link_name=\"%s%s\")\n", exp.namespace, fn.name)
+            fmt.sbprintf(&buf, "\t_%s%s :: proc(", exp.namespace, fn.name)
+         } else {
+            fmt.sbprintf(&buf, "\t%s%s :: proc(", exp.namespace, fn.name)
+         }
 
          for arg, i in fn.args {
             strings.write_string(&buf, arg)
@@ -102,17 +133,30 @@ foreign env {
             }
          }
 
+         stack_rets := make([dynamic]string)
+         params := len(fn.args)
+         for ret in fn.rets {
+            if !is_by_pointer(ret) {
+               append(&stack_rets, ret)
+               continue
+            }
+
+            if params > 0 do strings.write_string(&buf, ", ")
+            fmt.sbprintf(&buf, "^%s", ret)
+            params += 1
+         }
+
          strings.write_string(&buf, ") ")
 
-         if len(fn.rets) > 0 {
+         if len(stack_rets) > 0 {
             strings.write_string(&buf, "-> ")
 
-            multiple := len(fn.rets) > 1
+            multiple := len(stack_rets) > 1
             if multiple do strings.write_byte(&buf, '(')
 
-            for ret, i in fn.rets {
+            for ret, i in stack_rets {
                strings.write_string(&buf, ret)
-               if i < len(fn.args) - 1 {
+               if i < len(stack_rets) - 1 {
                   strings.write_string(&buf, ", ")
                }
             }
@@ -130,6 +174,74 @@ foreign env {
 
    strings.write_string(&buf, "}\n")
 
+   // Generate wrappers for procedures that return values through pointers
+   for exp in api.exports {
+      for fn in exp.functions {
+         if !returns_by_pointer(fn) do continue
+
+         fmt.sbprintf(&buf, "\n%s%s :: proc \"contextless\" (", exp.namespace, fn.name)
+
+         for arg, i in fn.args {
+            fmt.sbprintf(&buf, "a%d: %s", i, arg)
+            if i < len(fn.args) - 1 {
+               strings.write_string(&buf, ", ")
+            }
+         }
+
+         strings.write_string(&buf, ") -> (")
+
+         for ret, i in fn.rets {
+            fmt.sbprintf(&buf, "r%d: %s", i, ret)
+            if i < len(fn.rets) - 1 {
+               strings.write_string(&buf, ", ")
+            }
+         }
+
+         strings.write_string(&buf, ") {\n\t")
+
+         assigned := 0
+         for ret, i in fn.rets {
+            if is_by_pointer(ret) do continue
+            if assigned > 0 do strings.write_string(&buf, ", ")
+            fmt.sbprintf(&buf, "r%d", i)
+            assigned += 1
+         }
+
+         if assigned > 0 do strings.write_string(&buf, " = ")
+
+         fmt.sbprintf(&buf, "_%s%s(", exp.namespace, fn.name)
+
+         params := 0
+         for _, i in fn.args {
+            if params > 0 do strings.write_string(&buf, ", ")
+            fmt.sbprintf(&buf, "a%d", i)
+            params += 1
+         }
+
+         for ret, i in fn.rets {
+            if !is_by_pointer(ret) do continue
+            if params > 0 do strings.write_string(&buf, ", ")
+            fmt.sbprintf(&buf, "&r%d", i)
+            params += 1
+         }
+
+         strings.write_string(&buf, ")\n\treturn\n}\n")
+      }
+   }
+
+   if uses_pointers {
+      strings.write_string(&buf, `
+// Called by the engine to allocate memory for the values it returns.
+// The memory is owned by the caller and should be freed with the context allocator.
+@(export)
+alloc :: proc "c" (size: u32) -> rawptr {
+	context = runtime.default_context()
+	ptr, _ := runtime.mem_alloc(int(size))
+	return ptr
+}
+`)
+   }
+
    str := strings.to_string(buf)
    wok := os.write_entire_file("../bindings.odin", buf.buf[:], true)
    if !wok {
@@ -0,0 +1,306 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+/*
+
+Actions and axes give names to groups of input events so modules don't have to
+hard-code them. An action is held when any of its events are, while an axis
+adds up all of its bindings and clamps the result to -1..1.
+
+Bindings are kept by the engine, so they survive hot reloads. Binding an event
+that's already bound does nothing, which lets modules register their defaults
+in Setup without worrying about duplicates.
+
+Bindings can be saved to and loaded from a json file that uses the names from
+InputEvent.Export and GamepadAxis.Export:
+
+	{
+		"actions": { "jump": ["Space", "GamepadA"] },
+		"axes": { "move_x": [{ "negative": "A", "positive": "D" }, { "gamepad": "LeftX" }] }
+	}
+
+Gamepad events and axes refer to the gamepad in slot 0.
+
+*/
+
+type (
+	actionMap struct {
+		actions map[string][]InputEvent
+		axes    map[string][]axisBinding
+	}
+
+	// axisBinding is either a pair of digital events or an analog gamepad axis
+	axisBinding struct {
+		negative, positive InputEvent
+		gamepad            GamepadAxis
+		analog             bool
+	}
+
+	bindingsFile struct {
+		Actions map[string][]string          `json:"actions"`
+		Axes    map[string][]axisBindingFile `json:"axes"`
+	}
+
+	axisBindingFile struct {
+		Negative string `json:"negative,omitempty"`
+		Positive string `json:"positive,omitempty"`
+		Gamepad  string `json:"gamepad,omitempty"`
+	}
+)
+
+func (m *actionMap) setup() {
+	m.actions = make(map[string][]InputEvent)
+	m.axes = make(map[string][]axisBinding)
+}
+
+func (i *Input) BindAction(action string, e InputEvent) {
+	if !validEvent(e) {
+		LogWarn("input - unable to bind %d to action %q, it's not a valid event", e, action)
+		return
+	}
+
+	if !slices.Contains(i.bindings.actions[action], e) {
+		i.bindings.actions[action] = append(i.bindings.actions[action], e)
+	}
+}
+
+// BindAxis binds a pair of events to an axis. negative moves the axis towards -1, positive towards 1.
+func (i *Input) BindAxis(axis string, negative, positive InputEvent) {
+	if !validEvent(negative) || !validEvent(positive) {
+		LogWarn("input - unable to bind %d, %d to axis %q, they're not valid events", negative, positive, axis)
+		return
+	}
+
+	i.bindAxis(axis, axisBinding{negative: negative, positive: positive})
+}
+
+func (i *Input) BindGamepadAxis(axis string, gamepadAxis GamepadAxis) {
+	if gamepadAxis >= _gamepadAxisMax {
+		LogWarn("input - unable to bind %d to axis %q, it's not a valid gamepad axis", gamepadAxis, axis)
+		return
+	}
+
+	i.bindAxis(axis, axisBinding{gamepad: gamepadAxis, analog: true})
+}
+
+func (i *Input) bindAxis(axis string, b axisBinding) {
+	if !slices.Contains(i.bindings.axes[axis], b) {
+		i.bindings.axes[axis] = append(i.bindings.axes[axis], b)
+	}
+}
+
+// ClearBindings removes every binding from the action or axis with the given name
+func (i *Input) ClearBindings(name string) {
+	delete(i.bindings.actions, name)
+	delete(i.bindings.axes, name)
+}
+
+// actionHeld reports if any event bound to an action is down in the given frame
+func (i *Input) actionHeld(action string, frame *[_inputMax + 1]inputState) bool {
+	for _, e := range i.bindings.actions[action] {
+		if frame[e]&stateDown != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (i *Input) ActionJustPressed(action string) bool {
+	return !i.actionHeld(action, &i.lastFrame) && i.actionHeld(action, &i.thisFrame)
+}
+
+func (i *Input) ActionJustReleased(action string) bool {
+	return i.actionHeld(action, &i.lastFrame) && !i.actionHeld(action, &i.thisFrame)
+}
+
+func (i *Input) ActionHeld(action string) bool {
+	return i.actionHeld(action, &i.thisFrame)
+}
+
+// AxisValue returns the sum of every binding of an axis, between -1 and 1
+func (i *Input) AxisValue(axis string) float32 {
+	var value float32
+
+	for _, b := range i.bindings.axes[axis] {
+		if b.analog {
+			value += i.GamepadAxis(0, b.gamepad)
+			continue
+		}
+
+		if i.Held(b.negative) {
+			value -= 1
+		}
+
+		if i.Held(b.positive) {
+			value += 1
+		}
+	}
+
+	return min(max(value, -1), 1)
+}
+
+// LoadBindings replaces the bindings of every action and axis in a bindings file
+func (i *Input) LoadBindings(path string) bool {
+	data, err := os.ReadFile(brut.Asset.resolvePath(path))
+	if err != nil {
+		LogError("input - unable to read bindings %q: %s", path, err)
+		return false
+	}
+
+	err = i.bindings.unmarshal(data)
+	if err != nil {
+		LogError("input - unable to load bindings %q: %s", path, err)
+		return false
+	}
+
+	LogDebug("input - loaded bindings %q", path)
+	return true
+}
+
+// SaveBindings writes every action and axis to a bindings file
+func (i *Input) SaveBindings(path string) bool {
+	data, err := i.bindings.marshal()
+	if err == nil {
+		err = os.WriteFile(brut.Asset.resolvePath(path), data, 0o644)
+	}
+
+	if err != nil {
+		LogError("input - unable to save bindings %q: %s", path, err)
+		return false
+	}
+
+	LogDebug("input - saved bindings %q", path)
+	return true
+}
+
+func (m *actionMap) marshal() ([]byte, error) {
+	events := exportNames((*InputEvent).Export)
+	axes := exportNames((*GamepadAxis).Export)
+
+	file := bindingsFile{
+		Actions: make(map[string][]string, len(m.actions)),
+		Axes:    make(map[string][]axisBindingFile, len(m.axes)),
+	}
+
+	for action, bound := range m.actions {
+		names := make([]string, 0, len(bound))
+		for _, e := range bound {
+			names = append(names, events[e])
+		}
+
+		file.Actions[action] = names
+	}
+
+	for axis, bound := range m.axes {
+		bindings := make([]axisBindingFile, 0, len(bound))
+		for _, b := range bound {
+			if b.analog {
+				bindings = append(bindings, axisBindingFile{Gamepad: axes[b.gamepad]})
+			} else {
+				bindings = append(bindings, axisBindingFile{Negative: events[b.negative], Positive: events[b.positive]})
+			}
+		}
+
+		file.Axes[axis] = bindings
+	}
+
+	return json.MarshalIndent(file, "", "\t")
+}
+
+// unmarshal replaces the bindings of everything in data. Nothing is changed if data is invalid.
+func (m *actionMap) unmarshal(data []byte) error {
+	var file bindingsFile
+
+	err := json.Unmarshal(data, &file)
+	if err != nil {
+		return err
+	}
+
+	events := (*InputEvent)(nil).Export()
+	axes := (*GamepadAxis)(nil).Export()
+
+	event := func(name string) (InputEvent, error) {
+		e, ok := events[name]
+		if !ok {
+			return InputNone, fmt.Errorf("unknown input event %q", name)
+		}
+
+		return e, nil
+	}
+
+	actions := make(map[string][]InputEvent, len(file.Actions))
+	for action, names := range file.Actions {
+		// An empty list unbinds the action
+		actions[action] = make([]InputEvent, 0, len(names))
+
+		for _, name := range names {
+			e, err := event(name)
+			if err != nil {
+				return err
+			}
+
+			actions[action] = append(actions[action], e)
+		}
+	}
+
+	bound := make(map[string][]axisBinding, len(file.Axes))
+	for axis, bindings := range file.Axes {
+		bound[axis] = make([]axisBinding, 0, len(bindings))
+
+		for _, b := range bindings {
+			if b.Gamepad != "" {
+				a, ok := axes[b.Gamepad]
+				if !ok {
+					return fmt.Errorf("unknown gamepad axis %q", b.Gamepad)
+				}
+
+				bound[axis] = append(bound[axis], axisBinding{gamepad: a, analog: true})
+				continue
+			}
+
+			negative, err := event(b.Negative)
+			if err != nil {
+				return err
+			}
+
+			positive, err := event(b.Positive)
+			if err != nil {
+				return err
+			}
+
+			bound[axis] = append(bound[axis], axisBinding{negative: negative, positive: positive})
+		}
+	}
+
+	for action, events := range actions {
+		m.actions[action] = events
+	}
+
+	for axis, bindings := range bound {
+		m.axes[axis] = bindings
+	}
+
+	return nil
+}
+
+func validEvent(e InputEvent) bool {
+	_, ok := exportNames((*InputEvent).Export)[e]
+	return ok
+}
+
+// exportNames inverts an enum's Export map
+func exportNames[T comparable](export func(*T) map[string]T) map[T]string {
+	names := make(map[T]string)
+	for name, v := range export(nil) {
+		names[v] = name
+	}
+
+	return names
+}
@@ -4,25 +4,46 @@ import (
 	"bytes"
 	"image"
 	"os"
-
-	"github.com/hajimehoshi/ebiten/v2"
+	"path/filepath"
 )
 
 type (
 	Asset struct {
 		loadedTextures map[Texture]textureData
+		loadedAtlases  map[Atlas]atlasData
+		watcher        assetWatcher
 	}
 	IAsset interface {
 		LoadTexture(name string) Texture
+		TextureInfo(tex Texture) TextureInfo
+		ReadFile(name string) []byte
+		LoadAtlas(name string) Atlas
+		AtlasTexture(atlas Atlas) Texture
+		AtlasFrame(atlas Atlas, frame string) Texture
+		AtlasRegion(atlas Atlas, frame string) Rect
+		AtlasTrim(atlas Atlas, frame string) Rect
 	}
 
 	// Texture is a non-zero texture id that can be used to get textureData
 	Texture uint32
 
+	// TextureInfo describes a loaded texture
+	TextureInfo struct {
+		Width, Height int32
+	}
+
 	// textureData is the internal representation of a texture
 	textureData struct {
-		name   string
-		handle *ebiten.Image
+		name  string
+		image textureImage
+
+		// Textures cut from another (i.e. atlas frames) are rebuilt when it's reloaded
+		parent Texture
+		region image.Rectangle
+
+		// Trimmed atlas frames are drawn at offset within an area of size source
+		offset image.Point
+		source image.Point
 	}
 )
 
@@ -39,17 +60,20 @@ func (a *Asset) getTextureByName(name string) (Texture, bool) {
 	return InvalidTexture, false
 }
 
-func (a *Asset) GetTextureData(tex Texture) (*ebiten.Image, bool) {
-	data, ok := a.loadedTextures[tex]
-	if ok {
-		return data.handle, true
-	}
+// resolvePath returns the on-disk location of an asset relative to the asset root
+func (a *Asset) resolvePath(name string) string {
+	return filepath.Join(brut.Config.AssetRoot, name)
+}
 
-	return nil, false
+func (a *Asset) getTexture(tex Texture) (textureData, bool) {
+	data, ok := a.loadedTextures[tex]
+	return data, ok
 }
 
 func (a *Asset) Setup() error {
 	a.loadedTextures = make(map[Texture]textureData)
+	a.loadedAtlases = make(map[Atlas]atlasData)
+	a.watcher.setup()
 	return nil
 }
 
@@ -60,35 +84,117 @@ func (a *Asset) LoadTexture(name string) Texture {
 
 	LogDebug("asset - loading texture %q", name)
 
-	data, err := os.ReadFile(name)
+	tex, err := decodeTexture(name)
 	if err != nil {
-		LogError("asset - unable to load texture %q", name)
+		LogError("asset - unable to load texture %q! %s", name, err)
 		return InvalidTexture
 	}
 
-	buf := bytes.NewBuffer(data)
+	id := Texture(len(a.loadedTextures) + 1)
+	a.loadedTextures[id] = tex
+	a.watch(name, func() { a.reloadTexture(id) })
+
+	LogDebug("asset - texture loaded!")
+	return id
+}
+
+func decodeTexture(name string) (textureData, error) {
+	data, err := os.ReadFile(brut.Asset.resolvePath(name))
+	if err != nil {
+		return textureData{}, err
+	}
 
-	decoded, _, err := image.Decode(buf)
+	decoded, _, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
-		LogError("asset - unable to decode texture %q! %s", name, err)
-		return InvalidTexture
+		return textureData{}, err
 	}
 
-	img := ebiten.NewImageFromImage(decoded)
-	if img == nil {
-		LogError("asset - unable to create image from texture %q", name)
-		return InvalidTexture
+	img, err := newTextureImage(decoded)
+	if err != nil {
+		return textureData{}, err
 	}
 
-	id := Texture(len(a.loadedTextures) + 1)
+	return textureData{name: name, image: img}, nil
+}
+
+// reloadTexture replaces the image behind a texture and every texture cut from it
+func (a *Asset) reloadTexture(id Texture) {
+	old := a.loadedTextures[id]
+
+	tex, err := decodeTexture(old.name)
+	if err != nil {
+		LogError("asset - unable to reload texture %q, keeping the previous version! %s", old.name, err)
+		return
+	}
 
-	a.loadedTextures[id] = textureData{
+	a.loadedTextures[id] = tex
+	bounds := tex.bounds()
+
+	for childID, child := range a.loadedTextures {
+		if child.parent == id {
+			// The image may have shrunk, regions are kept so the texture is whole again if it grows back
+			if !child.region.In(bounds) {
+				LogError("asset - %q is outside of %q, which is now %dx%d, it'll be clipped", child.name, old.name, bounds.Dx(), bounds.Dy())
+			}
+
+			sub := subTexture(tex, child.name, id, child.region)
+			sub.offset, sub.source = child.offset, child.source
+			a.loadedTextures[childID] = sub
+		}
+	}
+
+	old.image.dispose()
+
+	LogInfo("asset - reloaded texture %q", old.name)
+}
+
+// subTexture creates a texture covering part of another, sharing its memory
+func subTexture(parent textureData, name string, parentID Texture, region image.Rectangle) textureData {
+	return textureData{
 		name:   name,
-		handle: img,
+		image:  parent.image.subImage(region),
+		parent: parentID,
+		region: region,
 	}
+}
 
-	LogDebug("asset - texture loaded!")
-	return id
+func (a *Asset) TextureInfo(tex Texture) TextureInfo {
+	data, ok := a.getTexture(tex)
+	if !ok {
+		return TextureInfo{}
+	}
+
+	size := data.size()
+	return TextureInfo{
+		Width:  int32(size.X),
+		Height: int32(size.Y),
+	}
+}
+
+// ReadFile returns the contents of a file relative to the asset root
+func (a *Asset) ReadFile(name string) []byte {
+	data, err := os.ReadFile(a.resolvePath(name))
+	if err != nil {
+		LogError("asset - unable to read %q: %s", name, err)
+		return nil
+	}
+
+	return data
+}
+
+// bounds returns the area of the underlying image the texture covers.
+// Textures created from atlas frames don't start at the origin.
+func (t textureData) bounds() image.Rectangle {
+	return t.image.bounds()
+}
+
+// size returns the size of the texture, which is larger than its bounds for trimmed atlas frames
+func (t textureData) size() image.Point {
+	if t.source != (image.Point{}) {
+		return t.source
+	}
+
+	return t.bounds().Size()
 }
 
 var _ IAsset = (*Asset)(nil)
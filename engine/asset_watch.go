@@ -0,0 +1,156 @@
+package engine
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+/*
+
+While hot reloading is enabled, every asset file that's loaded is watched for
+changes. Asset types register a reload function for their file with
+Asset.watch, which is called on the game loop (between ticks) once the file has
+stopped changing for reloadDebounce. Reloads replace the data behind an existing
+id, so modules keep using the ids they already have.
+
+If a changed file can't be loaded, the previous version is kept.
+
+*/
+
+type assetWatcher struct {
+	mut     sync.Mutex
+	watcher *fsnotify.Watcher // nil until hot reloading is enabled
+	dirs    map[string]bool
+	reloads map[string][]func() // keyed by absolute path
+	pending map[string]time.Time
+}
+
+func (w *assetWatcher) setup() {
+	w.dirs = make(map[string]bool)
+	w.reloads = make(map[string][]func())
+	w.pending = make(map[string]time.Time)
+}
+
+// watch calls reload whenever the asset with the given name changes
+func (a *Asset) watch(name string, reload func()) {
+	path, err := filepath.Abs(a.resolvePath(name))
+	if err != nil {
+		LogWarn("asset - unable to watch %q: %s", name, err)
+		return
+	}
+
+	w := &a.watcher
+
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	w.reloads[path] = append(w.reloads[path], reload)
+
+	// Directories are watched so files replaced by renaming are still seen
+	dir := filepath.Dir(path)
+	if !w.dirs[dir] {
+		w.dirs[dir] = true
+		w.addDir(dir)
+	}
+}
+
+// addDir watches a directory if hot reloading has been enabled, w must be locked
+func (w *assetWatcher) addDir(dir string) {
+	if w.watcher == nil {
+		return
+	}
+
+	err := w.watcher.Add(dir)
+	if err != nil {
+		LogWarn("asset - unable to watch %s: %s", dir, err)
+	}
+}
+
+// watchAssets starts watching every asset that has been, or will be, loaded
+func (a *Asset) watchAssets() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		LogWarn("asset - unable to setup watcher: %s", err)
+		return
+	}
+
+	w := &a.watcher
+
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	w.watcher = watcher
+	for dir := range w.dirs {
+		w.addDir(dir)
+	}
+
+	go w.watchForChanges(watcher)
+}
+
+func (w *assetWatcher) watchForChanges(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			if !(event.Has(fsnotify.Write) || event.Has(fsnotify.Create)) {
+				continue
+			}
+
+			w.mut.Lock()
+			if _, ok := w.reloads[event.Name]; ok {
+				w.pending[event.Name] = time.Now()
+			}
+			w.mut.Unlock()
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+
+			LogError("asset - %s", err)
+		}
+	}
+}
+
+// Update reloads assets that have finished changing
+func (a *Asset) Update() {
+	w := &a.watcher
+
+	var reloads []func()
+
+	w.mut.Lock()
+	for path, changed := range w.pending {
+		if time.Since(changed) < reloadDebounce {
+			continue
+		}
+
+		LogDebug("asset - reloading %q", path)
+
+		reloads = append(reloads, w.reloads[path]...)
+		delete(w.pending, path)
+	}
+	w.mut.Unlock()
+
+	// Reload functions may load other assets, which needs the lock
+	for _, reload := range reloads {
+		reload()
+	}
+}
+
+func (a *Asset) Teardown() {
+	w := &a.watcher
+
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	if w.watcher != nil {
+		_ = w.watcher.Close()
+		w.watcher = nil
+	}
+}
@@ -0,0 +1,326 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"path/filepath"
+)
+
+type (
+	// Atlas is a non-zero atlas id that can be used to get atlasData
+	Atlas uint32
+
+	// atlasData is the internal representation of a texture atlas
+	atlasData struct {
+		name    string
+		texture Texture
+		frames  map[string]atlasRegion
+		sprites map[string]Texture // frames that have been requested as textures
+	}
+
+	// atlasRegion is the area of the atlas texture a frame occupies.
+	// Trimmed frames had their transparent edges removed when packed; offset is where rect sits within the original sprite.
+	atlasRegion struct {
+		rect   image.Rectangle
+		offset image.Point
+		source image.Point
+	}
+
+	// atlasFile is the json written by TexturePacker and Aseprite.
+	// Frames are either an object keyed by name (hash) or a list of named frames (array).
+	atlasFile struct {
+		Frames json.RawMessage `json:"frames"`
+		Meta   struct {
+			Image string `json:"image"`
+		} `json:"meta"`
+	}
+
+	atlasFrame struct {
+		Filename         string    `json:"filename"`
+		Rotated          bool      `json:"rotated"`
+		Trimmed          bool      `json:"trimmed"`
+		Frame            atlasRect `json:"frame"`
+		SpriteSourceSize atlasRect `json:"spriteSourceSize"`
+		SourceSize       struct {
+			W int `json:"w"`
+			H int `json:"h"`
+		} `json:"sourceSize"`
+	}
+
+	atlasRect struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+		W int `json:"w"`
+		H int `json:"h"`
+	}
+)
+
+// InvalidAtlas is used to signal when an atlas was unable to be loaded or fetched
+const InvalidAtlas Atlas = 0
+
+func (a *Asset) getAtlasByName(name string) (Atlas, bool) {
+	for id, data := range a.loadedAtlases {
+		if data.name == name {
+			return id, true
+		}
+	}
+
+	return InvalidAtlas, false
+}
+
+// LoadAtlas loads an atlas description and the texture it references.
+// The texture is loaded relative to the description.
+func (a *Asset) LoadAtlas(name string) Atlas {
+	if id, ok := a.getAtlasByName(name); ok {
+		return id
+	}
+
+	LogDebug("asset - loading atlas %q", name)
+
+	data := a.ReadFile(name)
+	if data == nil {
+		return InvalidAtlas
+	}
+
+	atlas, texture, err := parseAtlas(data)
+	if err != nil {
+		LogError("asset - unable to parse atlas %q! %s", name, err)
+		return InvalidAtlas
+	}
+
+	atlas.name = name
+	atlas.texture = a.LoadTexture(filepath.Join(filepath.Dir(name), texture))
+	if atlas.texture == InvalidTexture {
+		LogError("asset - unable to load texture for atlas %q", name)
+		return InvalidAtlas
+	}
+
+	id := Atlas(len(a.loadedAtlases) + 1)
+	a.loadedAtlases[id] = atlas
+	a.watch(name, func() { a.reloadAtlas(id) })
+
+	LogDebug("asset - atlas loaded with %d frames!", len(atlas.frames))
+	return id
+}
+
+// AtlasTexture returns the texture containing every frame of the atlas
+func (a *Asset) AtlasTexture(atlas Atlas) Texture {
+	data, ok := a.loadedAtlases[atlas]
+	if !ok {
+		return InvalidTexture
+	}
+
+	return data.texture
+}
+
+// AtlasRegion returns the area of the atlas texture a frame occupies.
+// The region can be given to TextureRegion or DrawCommand.Source.
+func (a *Asset) AtlasRegion(atlas Atlas, frame string) Rect {
+	data, ok := a.loadedAtlases[atlas]
+	if !ok {
+		return Rect{}
+	}
+
+	r, ok := data.frames[frame]
+	if !ok {
+		LogError("asset - atlas %q has no frame %q", data.name, frame)
+		return Rect{}
+	}
+
+	return Rect{
+		X: float32(r.rect.Min.X),
+		Y: float32(r.rect.Min.Y),
+		W: float32(r.rect.Dx()),
+		H: float32(r.rect.Dy()),
+	}
+}
+
+// AtlasTrim returns where a frame's region sits within its original sprite (X, Y) and the sprite's size (W, H).
+// Frames that weren't trimmed are at 0, 0 and as large as their region.
+// Textures from AtlasFrame are offset automatically, this is for drawing with AtlasRegion.
+func (a *Asset) AtlasTrim(atlas Atlas, frame string) Rect {
+	data, ok := a.loadedAtlases[atlas]
+	if !ok {
+		return Rect{}
+	}
+
+	r, ok := data.frames[frame]
+	if !ok {
+		LogError("asset - atlas %q has no frame %q", data.name, frame)
+		return Rect{}
+	}
+
+	return Rect{
+		X: float32(r.offset.X),
+		Y: float32(r.offset.Y),
+		W: float32(r.source.X),
+		H: float32(r.source.Y),
+	}
+}
+
+// AtlasFrame returns a texture that only contains the given frame.
+// The texture shares memory with the atlas texture. Trimmed frames report the size of their original sprite and are drawn at their offset within it.
+func (a *Asset) AtlasFrame(atlas Atlas, frame string) Texture {
+	data, ok := a.loadedAtlases[atlas]
+	if !ok {
+		return InvalidTexture
+	}
+
+	if id, ok := data.sprites[frame]; ok {
+		return id
+	}
+
+	r, ok := data.frames[frame]
+	if !ok {
+		LogError("asset - atlas %q has no frame %q", data.name, frame)
+		return InvalidTexture
+	}
+
+	parent, ok := a.getTexture(data.texture)
+	if !ok {
+		return InvalidTexture
+	}
+
+	if !r.fits(parent) {
+		size := parent.bounds().Size()
+		LogError("asset - frame %q of atlas %q is outside of its %dx%d texture", frame, data.name, size.X, size.Y)
+		return InvalidTexture
+	}
+
+	id := Texture(len(a.loadedTextures) + 1)
+	a.loadedTextures[id] = r.texture(parent, data.name+"#"+frame, data.texture)
+	data.sprites[frame] = id
+
+	return id
+}
+
+// reloadAtlas reads an atlas description again, updating the frames of textures returned by AtlasFrame
+func (a *Asset) reloadAtlas(id Atlas) {
+	old := a.loadedAtlases[id]
+
+	data := a.ReadFile(old.name)
+	if data == nil {
+		return
+	}
+
+	atlas, texture, err := parseAtlas(data)
+	if err != nil {
+		LogError("asset - unable to reload atlas %q, keeping the previous version! %s", old.name, err)
+		return
+	}
+
+	atlas.name = old.name
+	atlas.texture = a.LoadTexture(filepath.Join(filepath.Dir(old.name), texture))
+	if atlas.texture == InvalidTexture {
+		LogError("asset - unable to load texture for atlas %q, keeping the previous version", old.name)
+		return
+	}
+
+	parent, _ := a.getTexture(atlas.texture)
+	size := parent.bounds().Size()
+
+	for frame, r := range atlas.frames {
+		if !r.fits(parent) {
+			LogError("asset - frame %q of atlas %q is outside of its %dx%d texture", frame, old.name, size.X, size.Y)
+		}
+	}
+
+	for frame, sprite := range old.sprites {
+		r, ok := atlas.frames[frame]
+		if !ok {
+			LogWarn("asset - atlas %q no longer has frame %q, keeping its previous region", old.name, frame)
+			r = old.frames[frame]
+		} else if !r.fits(parent) {
+			r = old.frames[frame]
+		}
+
+		a.loadedTextures[sprite] = r.texture(parent, a.loadedTextures[sprite].name, atlas.texture)
+		atlas.frames[frame] = r
+		atlas.sprites[frame] = sprite
+	}
+
+	a.loadedAtlases[id] = atlas
+	LogInfo("asset - reloaded atlas %q", old.name)
+}
+
+// parseAtlas reads TexturePacker and Aseprite json (hash or array) and returns the atlas and the texture it references
+func parseAtlas(data []byte) (atlasData, string, error) {
+	var file atlasFile
+
+	err := json.Unmarshal(data, &file)
+	if err != nil {
+		return atlasData{}, "", err
+	}
+
+	if file.Meta.Image == "" {
+		return atlasData{}, "", fmt.Errorf("missing meta.image")
+	}
+
+	var frames []atlasFrame
+
+	if len(file.Frames) > 0 && file.Frames[0] == '[' {
+		err = json.Unmarshal(file.Frames, &frames)
+	} else {
+		var hash map[string]atlasFrame
+
+		err = json.Unmarshal(file.Frames, &hash)
+		for name, frame := range hash {
+			frame.Filename = name
+			frames = append(frames, frame)
+		}
+	}
+
+	if err != nil {
+		return atlasData{}, "", err
+	}
+
+	atlas := atlasData{
+		frames:  make(map[string]atlasRegion, len(frames)),
+		sprites: make(map[string]Texture),
+	}
+
+	for _, frame := range frames {
+		// Packers disagree on which way rotated frames are turned, so they aren't guessed at
+		if frame.Rotated {
+			return atlasData{}, "", fmt.Errorf("frame %q is rotated, rotated frames are not supported (disable rotation when packing)", frame.Filename)
+		}
+
+		f := frame.Frame
+		r := atlasRegion{
+			rect:   image.Rect(f.X, f.Y, f.X+f.W, f.Y+f.H),
+			source: image.Pt(f.W, f.H),
+		}
+
+		if frame.Trimmed {
+			trim := frame.SpriteSourceSize
+			if trim.W != f.W || trim.H != f.H {
+				return atlasData{}, "", fmt.Errorf("frame %q is %dx%d but its spriteSourceSize is %dx%d", frame.Filename, f.W, f.H, trim.W, trim.H)
+			}
+
+			r.offset = image.Pt(trim.X, trim.Y)
+			r.source = image.Pt(frame.SourceSize.W, frame.SourceSize.H)
+
+			if !r.rect.Sub(r.rect.Min).Add(r.offset).In(image.Rect(0, 0, r.source.X, r.source.Y)) {
+				return atlasData{}, "", fmt.Errorf("frame %q does not fit within its sourceSize", frame.Filename)
+			}
+		}
+
+		atlas.frames[frame.Filename] = r
+	}
+
+	return atlas, file.Meta.Image, nil
+}
+
+// fits reports whether the frame is within the atlas texture, frames that aren't are clipped to it when cut out
+func (r atlasRegion) fits(parent textureData) bool {
+	return r.rect.In(parent.bounds())
+}
+
+// texture cuts the frame out of the atlas texture
+func (r atlasRegion) texture(parent textureData, name string, parentID Texture) textureData {
+	tex := subTexture(parent, name, parentID, r.rect)
+	tex.offset = r.offset
+	tex.source = r.source
+	return tex
+}
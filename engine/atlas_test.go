@@ -0,0 +1,204 @@
+package engine
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const trimmedAtlas = `{
+	"frames": {
+		"trimmed": {
+			"frame": {"x": 1, "y": 0, "w": 2, "h": 3},
+			"rotated": false,
+			"trimmed": true,
+			"spriteSourceSize": {"x": 1, "y": 2, "w": 2, "h": 3},
+			"sourceSize": {"w": 5, "h": 6}
+		},
+		"whole": {
+			"frame": {"x": 3, "y": 0, "w": 1, "h": 1},
+			"rotated": false,
+			"trimmed": false,
+			"spriteSourceSize": {"x": 0, "y": 0, "w": 1, "h": 1},
+			"sourceSize": {"w": 1, "h": 1}
+		}
+	},
+	"meta": {"image": "sheet.png"}
+}`
+
+func TestParseAtlas(t *testing.T) {
+	atlas, texture, err := parseAtlas([]byte(trimmedAtlas))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if texture != "sheet.png" {
+		t.Errorf("expected texture sheet.png, got %q", texture)
+	}
+
+	expected := map[string]atlasRegion{
+		"trimmed": {rect: image.Rect(1, 0, 3, 3), offset: image.Pt(1, 2), source: image.Pt(5, 6)},
+		"whole":   {rect: image.Rect(3, 0, 4, 1), source: image.Pt(1, 1)},
+	}
+
+	for name, want := range expected {
+		if got := atlas.frames[name]; got != want {
+			t.Errorf("frame %q: expected %+v, got %+v", name, want, got)
+		}
+	}
+}
+
+func TestParseAtlasErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace [2]string
+		err     string
+	}{
+		{name: "rotated", replace: [2]string{`"rotated": false`, `"rotated": true`}, err: "rotated frames are not supported"},
+		{name: "trim size mismatch", replace: [2]string{`"spriteSourceSize": {"x": 1, "y": 2, "w": 2`, `"spriteSourceSize": {"x": 1, "y": 2, "w": 3`}, err: "spriteSourceSize"},
+		{name: "outside source", replace: [2]string{`"sourceSize": {"w": 5, "h": 6}`, `"sourceSize": {"w": 2, "h": 6}`}, err: "sourceSize"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := strings.Replace(trimmedAtlas, test.replace[0], test.replace[1], 1)
+
+			_, _, err := parseAtlas([]byte(data))
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestDrawTrimmedFrame(t *testing.T) {
+	atlas, _, err := parseAtlas([]byte(trimmedAtlas))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	red := color.RGBA{255, 0, 0, 255}
+
+	sheet := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	for y := 0; y < 3; y += 1 {
+		for x := 1; x < 3; x += 1 {
+			sheet.SetRGBA(x, y, red)
+		}
+	}
+
+	parent := textureData{name: "sheet.png", image: softwareImage{sheet}}
+	frame := atlas.frames["trimmed"].texture(parent, "sheet.png#trimmed", 1)
+
+	if size := frame.size(); size != image.Pt(5, 6) {
+		t.Errorf("expected the frame to be the size of its source (5x6), got %s", size)
+	}
+
+	tests := []struct {
+		name string
+		flip Flip
+		at   image.Rectangle
+	}{
+		{name: "offset", at: image.Rect(1, 2, 3, 5)},
+		{name: "flipped within source", flip: FlipX | FlipY, at: image.Rect(2, 1, 4, 4)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target := newSoftwareTarget(8, 8)
+			g := Graphics{target: target}
+
+			g.drawTexture(&frame, &DrawCommand{ScaleX: 1, ScaleY: 1, Flip: test.flip, Color: Color{1, 1, 1, 1}})
+
+			for y := 0; y < 8; y += 1 {
+				for x := 0; x < 8; x += 1 {
+					want := color.RGBA{}
+					if image.Pt(x, y).In(test.at) {
+						want = red
+					}
+
+					if got := target.RGBAAt(x, y); got != want {
+						t.Fatalf("pixel %d,%d: expected %v, got %v", x, y, want, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAtlasFrameBounds(t *testing.T) {
+	startModule(t, newTestModule().bytes(), Config{})
+
+	a := &brut.Asset
+	root := brut.Config.AssetRoot
+
+	writeAtlas := func(data string) {
+		err := os.WriteFile(filepath.Join(root, "atlas.json"), []byte(data), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeSheet := func(w, h int) {
+		err := WritePNG(filepath.Join(root, "sheet.png"), image.NewRGBA(image.Rect(0, 0, w, h)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The sheet is 4x3, "outside" hangs off its right edge
+	writeSheet(4, 3)
+	writeAtlas(strings.Replace(trimmedAtlas, `"whole": {`, `"outside": {
+			"frame": {"x": 3, "y": 1, "w": 2, "h": 2},
+			"rotated": false,
+			"trimmed": false,
+			"spriteSourceSize": {"x": 0, "y": 0, "w": 2, "h": 2},
+			"sourceSize": {"w": 2, "h": 2}
+		},
+		"whole": {`, 1))
+
+	atlas := a.LoadAtlas("atlas.json")
+	if atlas == InvalidAtlas {
+		t.Fatal("unable to load atlas.json")
+	}
+
+	if a.AtlasFrame(atlas, "outside") != InvalidTexture {
+		t.Error("expected a frame outside of the texture to be rejected")
+	}
+
+	whole := a.AtlasFrame(atlas, "whole")
+	if whole == InvalidTexture {
+		t.Fatal("expected a frame inside of the texture to be usable")
+	}
+
+	region := image.Rect(3, 0, 4, 1)
+
+	// Moving the frame off the texture keeps its previous region
+	writeAtlas(strings.Replace(trimmedAtlas, `"frame": {"x": 3`, `"frame": {"x": 4`, 1))
+	a.reloadAtlas(atlas)
+
+	if got := a.loadedTextures[whole].region; got != region {
+		t.Errorf("expected the frame to keep its region %s after a reload moved it outside the texture, got %s", region, got)
+	}
+
+	// Shrinking the texture clips the frame to nothing, but keeps its region in case the texture grows back
+	writeSheet(2, 2)
+	a.reloadTexture(a.AtlasTexture(atlas))
+
+	frame := a.loadedTextures[whole]
+	if !frame.bounds().Empty() || frame.region != region {
+		t.Errorf("expected the frame to be clipped to the texture and keep its region, got bounds %s and region %s", frame.bounds(), frame.region)
+	}
+
+	g := Graphics{target: newSoftwareTarget(8, 8)}
+	g.drawTexture(&frame, &DrawCommand{ScaleX: 1, ScaleY: 1, Color: Color{1, 1, 1, 1}})
+
+	writeSheet(4, 3)
+	a.reloadTexture(a.AtlasTexture(atlas))
+
+	if got := a.loadedTextures[whole].bounds(); got != region {
+		t.Errorf("expected the frame to be whole again once the texture grew back, got %s", got)
+	}
+}
@@ -0,0 +1,615 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/mp3"
+	"github.com/hajimehoshi/ebiten/v2/audio/vorbis"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+)
+
+type (
+	Audio struct {
+		context      *audio.Context // nil when running headless
+		player       *audio.Player  // plays the mix
+		mixer        mixer
+		loadedSounds map[Sound]soundData
+		nextVoice    Voice
+		stream       pcmStream
+		streamVoice  Voice
+
+		// When headless the mix is pulled once per tick and optionally written to a wav file
+		wav           *wavWriter
+		pendingFrames float64
+		tickBuffer    []byte
+	}
+	IAudio interface {
+		LoadSound(name string) Sound
+		LoadMusic(name string) Sound
+		Play(sound Sound, loop bool) Voice
+		Pause(v Voice)
+		Resume(v Voice)
+		Stop(v Voice)
+		Playing(v Voice) bool
+		SetVolume(v Voice, volume float32)
+		SetPan(v Voice, pan float32)
+		SetPitch(v Voice, pitch float32)
+		SetVoiceBus(v Voice, bus Bus)
+		StartStream() Voice
+		PushSamples(samples []float32) int32
+		QueuedSamples() int32
+		SetBusVolume(bus Bus, volume float32)
+		SetBusMuted(bus Bus, muted bool)
+		SetBusLowPass(bus Bus, cutoff float32)
+		SetBusReverb(bus Bus, mix float32)
+		SetBusDucking(bus Bus, trigger Bus, amount float32)
+	}
+
+	// Sound is a non-zero sound id that can be used to get soundData
+	Sound uint32
+
+	// Voice is a non-zero id for a playing sound. Ids are never reused.
+	Voice uint32
+
+	// soundData is the internal representation of a sound
+	soundData struct {
+		name string
+		pcm  []byte // decoded samples, nil for music which is streamed from disk
+		bus  Bus    // bus new voices are played on
+	}
+
+	voice struct {
+		stream *voiceStream
+		file   io.Closer // open while music is streaming
+		sound  Sound
+		loop   bool
+		bus    Bus
+		volume float32
+		paused bool
+		ended  bool // set by the mixer once the stream runs out
+	}
+)
+
+// InvalidSound is used to signal when a sound was unable to be loaded or fetched
+const InvalidSound Sound = 0
+
+// InvalidVoice is returned when a sound couldn't be played
+const InvalidVoice Voice = 0
+
+// Every sound is decoded to 16-bit stereo at this rate
+const audioSampleRate = 44100
+
+func (a *Audio) Setup() error {
+	a.loadedSounds = make(map[Sound]soundData)
+	a.mixer.setup(&a.stream)
+
+	cfg := brut.Config
+
+	// There's no audio device to play to without a window, the mix is pulled each tick instead
+	if !cfg.Headless {
+		if cfg.AudioOut != "" {
+			LogWarn("audio - audio is only written to %q when running headless", cfg.AudioOut)
+		}
+
+		a.context = audio.NewContext(audioSampleRate)
+
+		player, err := a.context.NewPlayer(&a.mixer)
+		if err != nil {
+			return err
+		}
+
+		// Keep the delay between playing and hearing sounds short
+		player.SetBufferSize(streamLatency)
+		player.Play()
+
+		a.player = player
+		return nil
+	}
+
+	if cfg.AudioOut != "" {
+		w, err := newWavWriter(cfg.AudioOut, audioSampleRate)
+		if err != nil {
+			return err
+		}
+
+		LogInfo("audio - writing mix to %q", cfg.AudioOut)
+		a.wav = w
+	}
+
+	return nil
+}
+
+func (a *Audio) Teardown() {
+	if a.player != nil {
+		_ = a.player.Close()
+		a.player = nil
+	}
+
+	a.mixer.mut.Lock()
+	for id := range a.mixer.voices {
+		a.release(id)
+	}
+	a.mixer.mut.Unlock()
+
+	if a.wav != nil {
+		err := a.wav.Close()
+		if err != nil {
+			LogError("audio - unable to finish %q: %s", brut.Config.AudioOut, err)
+		}
+
+		a.wav = nil
+	}
+}
+
+// Update refills the stream from fill_audio and releases voices that have finished playing.
+// When headless it also mixes one tick of audio.
+func (a *Audio) Update() {
+	a.stream.refill()
+
+	if a.context == nil {
+		a.mixTick()
+	}
+
+	a.mixer.mut.Lock()
+	defer a.mixer.mut.Unlock()
+
+	for id, v := range a.mixer.voices {
+		if v.ended {
+			a.release(id)
+		}
+	}
+}
+
+func (a *Audio) mixTick() {
+	a.pendingFrames += float64(audioSampleRate) / float64(brut.Config.TickRate)
+
+	frames := int(a.pendingFrames)
+	a.pendingFrames -= float64(frames)
+
+	if cap(a.tickBuffer) < frames*4 {
+		a.tickBuffer = make([]byte, frames*4)
+	}
+
+	buf := a.tickBuffer[:frames*4]
+	_, _ = a.mixer.Read(buf)
+
+	if a.wav == nil {
+		return
+	}
+
+	_, err := a.wav.Write(buf)
+	if err != nil {
+		LogError("audio - unable to write to %q, no more audio will be written: %s", brut.Config.AudioOut, err)
+		_ = a.wav.Close()
+		a.wav = nil
+	}
+}
+
+func (a *Audio) getSoundByName(name string) (Sound, bool) {
+	for id, data := range a.loadedSounds {
+		if data.name == name {
+			return id, true
+		}
+	}
+
+	return InvalidSound, false
+}
+
+// decodeSound decodes a wav, ogg, or mp3 file based on its extension
+func decodeSound(name string, src io.Reader) (io.ReadSeeker, int64, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".wav":
+		s, err := wav.DecodeWithSampleRate(audioSampleRate, src)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return s, s.Length(), nil
+	case ".ogg":
+		s, err := vorbis.DecodeWithSampleRate(audioSampleRate, src)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return s, s.Length(), nil
+	case ".mp3":
+		s, err := mp3.DecodeWithSampleRate(audioSampleRate, src)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return s, s.Length(), nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported format %q, expected .wav, .ogg, or .mp3", filepath.Ext(name))
+	}
+}
+
+// LoadSound loads and decodes a sound effect into memory. It's played on BusSfx.
+func (a *Audio) LoadSound(name string) Sound {
+	if id, ok := a.getSoundByName(name); ok {
+		return id
+	}
+
+	LogDebug("audio - loading sound %q", name)
+
+	pcm, err := loadPCM(name)
+	if err != nil {
+		LogError("audio - unable to load sound %q! %s", name, err)
+		return InvalidSound
+	}
+
+	id := a.addSound(soundData{name: name, pcm: pcm, bus: BusSfx})
+	brut.Asset.watch(name, func() { a.reloadSound(id) })
+
+	return id
+}
+
+// loadPCM decodes a sound into memory
+func loadPCM(name string) ([]byte, error) {
+	data, err := os.ReadFile(brut.Asset.resolvePath(name))
+	if err != nil {
+		return nil, err
+	}
+
+	stream, _, err := decodeSound(name, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(stream)
+}
+
+// reloadSound decodes a sound again. Voices that are already playing keep the previous version.
+func (a *Audio) reloadSound(id Sound) {
+	data := a.loadedSounds[id]
+
+	pcm, err := loadPCM(data.name)
+	if err != nil {
+		LogError("audio - unable to reload sound %q, keeping the previous version! %s", data.name, err)
+		return
+	}
+
+	data.pcm = pcm
+	a.loadedSounds[id] = data
+
+	LogInfo("audio - reloaded sound %q", data.name)
+}
+
+// LoadMusic checks that a sound can be decoded, but leaves it on disk to be streamed while playing.
+// Long tracks should be loaded as music to avoid holding them in memory. It's played on BusMusic.
+func (a *Audio) LoadMusic(name string) Sound {
+	if id, ok := a.getSoundByName(name); ok {
+		return id
+	}
+
+	LogDebug("audio - loading music %q", name)
+
+	file, err := os.Open(brut.Asset.resolvePath(name))
+	if err != nil {
+		LogError("audio - unable to load music %q: %s", name, err)
+		return InvalidSound
+	}
+
+	defer file.Close()
+
+	_, _, err = decodeSound(name, file)
+	if err != nil {
+		LogError("audio - unable to decode music %q! %s", name, err)
+		return InvalidSound
+	}
+
+	id := a.addSound(soundData{name: name, bus: BusMusic})
+	brut.Asset.watch(name, func() { a.reloadMusic(id) })
+
+	return id
+}
+
+// reloadMusic restarts the voices playing a music track from its new version.
+// Unlike sounds they can't keep the previous version, as it's streamed from the file that changed.
+func (a *Audio) reloadMusic(id Sound) {
+	data := a.loadedSounds[id]
+
+	file, err := os.Open(brut.Asset.resolvePath(data.name))
+	if err == nil {
+		_, _, err = decodeSound(data.name, file)
+		_ = file.Close()
+	}
+
+	if err != nil {
+		LogError("audio - unable to reload music %q, voices playing it are left as they are! %s", data.name, err)
+		return
+	}
+
+	var playing []Voice
+
+	a.mixer.mut.Lock()
+	for vid, v := range a.mixer.voices {
+		if v.sound == id {
+			playing = append(playing, vid)
+		}
+	}
+	a.mixer.mut.Unlock()
+
+	for _, vid := range playing {
+		var loop bool
+		a.withVoice(vid, func(v *voice) { loop = v.loop })
+
+		stream, file, err := openVoiceStream(data, loop)
+		if err != nil {
+			LogError("audio - unable to restart voice %d of %q! %s", vid, data.name, err)
+			continue
+		}
+
+		replaced := false
+
+		a.withVoice(vid, func(v *voice) {
+			stream.pan, stream.pitch = v.stream.pan, v.stream.pitch
+
+			if v.file != nil {
+				_ = v.file.Close()
+			}
+
+			v.stream, v.file, v.ended = stream, file, false
+			replaced = true
+		})
+
+		// The voice was stopped in the meantime
+		if !replaced && file != nil {
+			_ = file.Close()
+		}
+	}
+
+	LogInfo("audio - reloaded music %q, restarted %d voices", data.name, len(playing))
+}
+
+func (a *Audio) addSound(data soundData) Sound {
+	id := Sound(len(a.loadedSounds) + 1)
+	a.loadedSounds[id] = data
+
+	LogDebug("audio - sound loaded!")
+	return id
+}
+
+// Play starts a new voice for sound. The voice is released once it finishes or is stopped.
+func (a *Audio) Play(sound Sound, loop bool) Voice {
+	data, ok := a.loadedSounds[sound]
+	if !ok {
+		return InvalidVoice
+	}
+
+	stream, file, err := openVoiceStream(data, loop)
+	if err != nil {
+		LogError("audio - unable to play %q: %s", data.name, err)
+		return InvalidVoice
+	}
+
+	v := &voice{stream: stream, file: file, sound: sound, loop: loop}
+	return a.addVoice(v, data.bus)
+}
+
+// openVoiceStream starts a stream of a sound from the beginning. Music is opened from disk, the file is returned to be closed with the voice.
+func openVoiceStream(data soundData, loop bool) (*voiceStream, io.Closer, error) {
+	var (
+		src    io.ReadSeeker
+		length int64
+		file   *os.File
+		err    error
+	)
+
+	if data.pcm != nil {
+		src, length = bytes.NewReader(data.pcm), int64(len(data.pcm))
+	} else {
+		file, err = os.Open(brut.Asset.resolvePath(data.name))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		src, length, err = decodeSound(data.name, file)
+		if err != nil {
+			_ = file.Close()
+			return nil, nil, err
+		}
+	}
+
+	var source io.Reader = src
+	if loop {
+		source = audio.NewInfiniteLoop(src, length)
+	}
+
+	stream := newVoiceStream(&pcmReader{src: bufio.NewReader(source)})
+
+	// A nil *os.File would make a non-nil io.Closer
+	if file == nil {
+		return stream, nil, nil
+	}
+
+	return stream, file, nil
+}
+
+func (a *Audio) addVoice(v *voice, bus Bus) Voice {
+	a.nextVoice += 1
+	id := a.nextVoice
+
+	v.bus = bus
+	v.volume = 1
+
+	a.mixer.mut.Lock()
+	a.mixer.voices[id] = v
+	a.mixer.mut.Unlock()
+
+	return id
+}
+
+// withVoice calls fn with the mixer locked if the voice exists
+func (a *Audio) withVoice(id Voice, fn func(v *voice)) {
+	a.mixer.mut.Lock()
+	defer a.mixer.mut.Unlock()
+
+	if v, ok := a.mixer.voices[id]; ok {
+		fn(v)
+	}
+}
+
+func (a *Audio) Pause(id Voice) {
+	a.withVoice(id, func(v *voice) { v.paused = true })
+}
+
+func (a *Audio) Resume(id Voice) {
+	a.withVoice(id, func(v *voice) { v.paused = false })
+}
+
+// Stop ends a voice and releases it, the id is invalid afterwards
+func (a *Audio) Stop(id Voice) {
+	a.mixer.mut.Lock()
+	defer a.mixer.mut.Unlock()
+
+	a.release(id)
+}
+
+// release removes a voice from the mixer, which must be locked
+func (a *Audio) release(id Voice) {
+	v, ok := a.mixer.voices[id]
+	if !ok {
+		return
+	}
+
+	if v.file != nil {
+		err := v.file.Close()
+		if err != nil {
+			LogWarn("audio - unable to stop voice %d: %s", id, err)
+		}
+	}
+
+	if id == a.streamVoice {
+		a.stream.setActive(false)
+		a.streamVoice = InvalidVoice
+	}
+
+	delete(a.mixer.voices, id)
+}
+
+// Playing reports if a voice is playing or paused
+func (a *Audio) Playing(id Voice) bool {
+	playing := false
+	a.withVoice(id, func(v *voice) { playing = !v.ended })
+	return playing
+}
+
+// SetVolume sets the volume of a voice from 0 (silent) to 1
+func (a *Audio) SetVolume(id Voice, volume float32) {
+	a.withVoice(id, func(v *voice) { v.volume = min(max(volume, 0), 1) })
+}
+
+// SetPan moves a voice between the left (-1) and right (1) speakers
+func (a *Audio) SetPan(id Voice, pan float32) {
+	a.withVoice(id, func(v *voice) { v.stream.pan = min(max(pan, -1), 1) })
+}
+
+// SetPitch changes the playback speed of a voice, 1 is the original pitch
+func (a *Audio) SetPitch(id Voice, pitch float32) {
+	a.withVoice(id, func(v *voice) { v.stream.pitch = min(max(pitch, 0.1), 4) })
+}
+
+// SetVoiceBus moves a voice to another bus
+func (a *Audio) SetVoiceBus(id Voice, bus Bus) {
+	if bus >= _busMax {
+		LogWarn("audio - %d is not a valid bus", bus)
+		return
+	}
+
+	a.withVoice(id, func(v *voice) { v.bus = bus })
+}
+
+// frameSource provides stereo frames with samples between -1 and 1
+type frameSource interface {
+	readFrame() ([2]float32, bool)
+}
+
+// pcmReader reads frames of 16-bit stereo samples
+type pcmReader struct {
+	src *bufio.Reader
+}
+
+func (r *pcmReader) readFrame() ([2]float32, bool) {
+	var b [4]byte
+
+	_, err := io.ReadFull(r.src, b[:])
+	if err != nil {
+		return [2]float32{}, false
+	}
+
+	return [2]float32{
+		float32(int16(binary.LittleEndian.Uint16(b[0:]))) / -math.MinInt16,
+		float32(int16(binary.LittleEndian.Uint16(b[2:]))) / -math.MinInt16,
+	}, true
+}
+
+// voiceStream applies pan and pitch to a source as it's mixed. It's only used while the mixer is locked.
+type voiceStream struct {
+	src   frameSource
+	pan   float32
+	pitch float32
+
+	// Output is interpolated between the current and next frame of src
+	cur, next [2]float32
+	frac      float32
+	hasNext   bool
+	primed    bool
+	ended     bool
+}
+
+func newVoiceStream(src frameSource) *voiceStream {
+	return &voiceStream{src: src, pitch: 1}
+}
+
+// mix adds the stream to dst, returning false once the stream has ended
+func (s *voiceStream) mix(dst []float32, volume float32) bool {
+	if !s.primed {
+		var ok bool
+
+		s.cur, ok = s.src.readFrame()
+		s.next, s.hasNext = s.src.readFrame()
+		s.ended = !ok
+		s.primed = true
+	}
+
+	left, right := min(1-s.pan, 1)*volume, min(1+s.pan, 1)*volume
+
+	for i := 0; i+1 < len(dst) && !s.ended; i += 2 {
+		dst[i] += (s.cur[0] + (s.next[0]-s.cur[0])*s.frac) * left
+		dst[i+1] += (s.cur[1] + (s.next[1]-s.cur[1])*s.frac) * right
+
+		s.frac += s.pitch
+		for s.frac >= 1 {
+			// The stream ends once the last frame has been played
+			if !s.hasNext {
+				s.ended = true
+				break
+			}
+
+			s.cur = s.next
+			s.next, s.hasNext = s.src.readFrame()
+			s.frac -= 1
+		}
+	}
+
+	return !s.ended
+}
+
+// Wasm api
+
+func (*Audio) Namespace() string {
+	return "Audio"
+}
+
+// Used to ensure Audio implements IAudio correctly
+var _ IAudio = (*Audio)(nil)
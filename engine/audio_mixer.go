@@ -0,0 +1,314 @@
+package engine
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+/*
+
+Every voice is mixed into one of the buses below, which apply their effects
+and volume before being mixed into BusMaster. Sounds start on BusSfx and music
+on BusMusic, Audio.SetVoiceBus moves a voice to another bus.
+
+Effects are applied in order: low-pass, reverb, volume/mute, then ducking.
+A ducked bus is turned down by a fraction of its volume while its trigger bus
+is making sound, e.g. ducking BusMusic by BusVoice so dialog can be heard.
+BusMaster can't be used as a trigger since it's mixed last.
+
+*/
+
+type Bus uint32
+
+const (
+	BusMaster Bus = iota
+	BusMusic
+	BusSfx
+	BusVoice
+	_busMax
+)
+
+func (*Bus) Export() map[string]Bus {
+	return map[string]Bus{
+		"Master": BusMaster,
+		"Music":  BusMusic,
+		"Sfx":    BusSfx,
+		"Voice":  BusVoice,
+	}
+}
+
+const (
+	duckThreshold = 0.01  // peak a trigger bus must reach to duck
+	duckAttack    = 0.01  // seconds to duck
+	duckRelease   = 0.25  // seconds to recover
+	reverbGain    = 0.045 // input gain of the reverb's comb filters
+)
+
+type (
+	mixer struct {
+		mut    sync.Mutex
+		stream *pcmStream
+		voices map[Voice]*voice
+		buses  [_busMax]busState
+		mix    [_busMax][]float32
+	}
+
+	busState struct {
+		volume float32
+		muted  bool
+
+		lowPass      float32 // filter coefficient, 0 when disabled
+		lowPassState [2]float32
+
+		reverbMix float32
+		reverb    *reverb // created the first time reverb is enabled
+
+		duckBy     Bus
+		duckAmount float32 // 0 when disabled
+		duckGain   float32
+
+		peak float32 // loudest sample of the last block, used for ducking
+	}
+)
+
+func (m *mixer) setup(stream *pcmStream) {
+	m.stream = stream
+	m.voices = make(map[Voice]*voice)
+
+	for i := range m.buses {
+		m.buses[i] = busState{volume: 1, duckGain: 1}
+	}
+}
+
+// Read mixes every voice into 16-bit stereo samples
+func (m *mixer) Read(p []byte) (int, error) {
+	frames := len(p) / 4
+
+	m.mut.Lock()
+	out := m.render(frames)
+	m.mut.Unlock()
+
+	for i, v := range out {
+		sample := int16(min(max(v, -1), 1) * math.MaxInt16)
+		binary.LittleEndian.PutUint16(p[i*2:], uint16(sample))
+	}
+
+	return frames * 4, nil
+}
+
+// render mixes frames of every voice, returning the output of BusMaster
+func (m *mixer) render(frames int) []float32 {
+	for b := range m.mix {
+		if cap(m.mix[b]) < frames*2 {
+			m.mix[b] = make([]float32, frames*2)
+		}
+
+		m.mix[b] = m.mix[b][:frames*2]
+		clear(m.mix[b])
+	}
+
+	for _, v := range m.voices {
+		if v.paused || v.ended {
+			continue
+		}
+
+		if !v.stream.mix(m.mix[v.bus], v.volume) {
+			v.ended = true
+		}
+	}
+
+	master := m.mix[BusMaster]
+
+	for b := BusMaster + 1; b < _busMax; b += 1 {
+		m.buses[b].process(m.mix[b])
+	}
+
+	for b := BusMaster + 1; b < _busMax; b += 1 {
+		bus := &m.buses[b]
+		bus.duck(m.mix[b], m.buses[bus.duckBy].peak)
+
+		for i, v := range m.mix[b] {
+			master[i] += v
+		}
+	}
+
+	m.buses[BusMaster].process(master)
+	m.buses[BusMaster].duck(master, m.buses[m.buses[BusMaster].duckBy].peak)
+
+	return master
+}
+
+func (b *busState) process(samples []float32) {
+	if b.lowPass > 0 {
+		for i := range samples {
+			ch := i & 1
+			b.lowPassState[ch] += (samples[i] - b.lowPassState[ch]) * b.lowPass
+			samples[i] = b.lowPassState[ch]
+		}
+	}
+
+	if b.reverbMix > 0 {
+		b.reverb.process(samples, b.reverbMix)
+	}
+
+	volume := b.volume
+	if b.muted {
+		volume = 0
+	}
+
+	b.peak = 0
+	for i := range samples {
+		samples[i] *= volume
+		b.peak = max(b.peak, float32(math.Abs(float64(samples[i]))))
+	}
+}
+
+func (b *busState) duck(samples []float32, triggerPeak float32) {
+	if b.duckAmount == 0 && b.duckGain == 1 {
+		return
+	}
+
+	target, speed := float32(1), float32(duckRelease)
+	if b.duckAmount > 0 && triggerPeak > duckThreshold {
+		target, speed = 1-b.duckAmount, duckAttack
+	}
+
+	// Move towards the target gain smoothly so ducking doesn't click
+	rate := 1 - float32(math.Exp(-1/(float64(speed)*audioSampleRate)))
+
+	for i := 0; i+1 < len(samples); i += 2 {
+		b.duckGain += (target - b.duckGain) * rate
+		samples[i] *= b.duckGain
+		samples[i+1] *= b.duckGain
+	}
+}
+
+// SetBusVolume sets the volume of a bus from 0 (silent) to 1
+func (a *Audio) SetBusVolume(bus Bus, volume float32) {
+	a.withBus(bus, func(b *busState) { b.volume = min(max(volume, 0), 1) })
+}
+
+// SetBusMuted silences a bus without changing its volume
+func (a *Audio) SetBusMuted(bus Bus, muted bool) {
+	a.withBus(bus, func(b *busState) { b.muted = muted })
+}
+
+// SetBusLowPass removes frequencies above cutoff (in hz) from a bus, 0 disables the filter
+func (a *Audio) SetBusLowPass(bus Bus, cutoff float32) {
+	a.withBus(bus, func(b *busState) {
+		if cutoff <= 0 {
+			b.lowPass = 0
+			return
+		}
+
+		b.lowPass = 1 - float32(math.Exp(-2*math.Pi*float64(cutoff)/audioSampleRate))
+	})
+}
+
+// SetBusReverb sets how much reverb (0-1) is mixed into a bus, 0 disables it
+func (a *Audio) SetBusReverb(bus Bus, mix float32) {
+	a.withBus(bus, func(b *busState) {
+		b.reverbMix = min(max(mix, 0), 1)
+		if b.reverbMix > 0 && b.reverb == nil {
+			b.reverb = newReverb()
+		}
+	})
+}
+
+// SetBusDucking turns a bus down by amount (0-1) while trigger is making sound, 0 disables ducking
+func (a *Audio) SetBusDucking(bus Bus, trigger Bus, amount float32) {
+	if trigger >= _busMax || trigger == BusMaster || trigger == bus {
+		LogWarn("audio - bus %d can't be ducked by %d", bus, trigger)
+		return
+	}
+
+	a.withBus(bus, func(b *busState) {
+		b.duckBy = trigger
+		b.duckAmount = min(max(amount, 0), 1)
+	})
+}
+
+// withBus calls fn with the mixer locked if the bus exists
+func (a *Audio) withBus(bus Bus, fn func(b *busState)) {
+	if bus >= _busMax {
+		LogWarn("audio - %d is not a valid bus", bus)
+		return
+	}
+
+	a.mixer.mut.Lock()
+	defer a.mixer.mut.Unlock()
+
+	fn(&a.mixer.buses[bus])
+}
+
+type (
+	// reverb is a small Schroeder reverb: parallel comb filters followed by allpass filters, for each channel
+	reverb struct {
+		combs     [2][4]delayLine
+		allpasses [2][2]delayLine
+	}
+
+	delayLine struct {
+		buf   []float32
+		pos   int
+		store float32 // last output of the comb's damping filter
+	}
+)
+
+const (
+	reverbFeedback = 0.84
+	reverbDamping  = 0.2
+	allpassGain    = 0.5
+	reverbSpread   = 23 // extra delay of the right channel so it doesn't match the left
+)
+
+func newReverb() *reverb {
+	// Delays (in samples at 44100 hz) from Freeverb
+	combDelays := [4]int{1116, 1188, 1277, 1356}
+	allpassDelays := [2]int{556, 441}
+
+	r := &reverb{}
+	for ch := range r.combs {
+		for i, d := range combDelays {
+			r.combs[ch][i].buf = make([]float32, d+ch*reverbSpread)
+		}
+
+		for i, d := range allpassDelays {
+			r.allpasses[ch][i].buf = make([]float32, d+ch*reverbSpread)
+		}
+	}
+
+	return r
+}
+
+func (r *reverb) process(samples []float32, mix float32) {
+	for i, dry := range samples {
+		ch := i & 1
+		in := dry * reverbGain
+
+		var wet float32
+		for c := range r.combs[ch] {
+			comb := &r.combs[ch][c]
+
+			out := comb.buf[comb.pos]
+			comb.store = out*(1-reverbDamping) + comb.store*reverbDamping
+			comb.buf[comb.pos] = in + comb.store*reverbFeedback
+			comb.pos = (comb.pos + 1) % len(comb.buf)
+
+			wet += out
+		}
+
+		for a := range r.allpasses[ch] {
+			allpass := &r.allpasses[ch][a]
+
+			delayed := allpass.buf[allpass.pos]
+			allpass.buf[allpass.pos] = wet + delayed*allpassGain
+			allpass.pos = (allpass.pos + 1) % len(allpass.buf)
+
+			wet = delayed - wet
+		}
+
+		samples[i] = dry*(1-mix) + wet*mix
+	}
+}
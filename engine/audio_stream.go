@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+/*
+
+Modules can generate audio themselves by starting a stream with Audio.StartStream.
+Samples are interleaved stereo float32s between -1 and 1, played at 44100 hz.
+
+There are two ways to feed a stream:
+
+	- Push samples each tick with Audio.PushSamples. They're queued in a ring
+	  buffer holding half a second of audio, Audio.QueuedSamples reports how
+	  many are waiting so a module can keep it topped up without adding latency.
+
+	- Export 'fill_audio(ptr: u32, frames: u32)'. The engine calls it once per
+	  tick on the game loop, asking for enough frames to keep a tenth of a
+	  second queued, and the module writes frames*2 samples to ptr. The buffer
+	  is allocated once with the module's 'alloc' export and reused.
+
+Samples from fill_audio are queued in the same ring buffer, so both can be
+used together. The audio player only reads from the ring buffer and never
+calls into the module. If the stream runs out of samples, silence is played
+until more arrive.
+
+*/
+
+const (
+	streamBufferSamples = audioSampleRate // half a second of stereo samples
+	streamLatency       = 50 * time.Millisecond
+
+	// streamFillSamples is how many samples fill_audio keeps queued, a tenth of a second.
+	// It's more than a tick and the player's buffer so the player doesn't run out between ticks.
+	streamFillSamples = streamBufferSamples / 5
+)
+
+// pcmStream queues samples from the module until they're mixed
+type pcmStream struct {
+	mut     sync.Mutex
+	ring    [streamBufferSamples]float32
+	start   int
+	count   int
+	active  bool      // set while the stream's voice exists
+	scratch []float32 // only used by refill
+}
+
+func (s *pcmStream) setActive(active bool) {
+	s.mut.Lock()
+	s.active = active
+	s.mut.Unlock()
+}
+
+// push queues as many whole frames of samples as will fit, returning the number of samples queued
+func (s *pcmStream) push(samples []float32) int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	n := min(len(samples), len(s.ring)-s.count) &^ 1
+	for i := 0; i < n; i += 1 {
+		v := samples[i]
+
+		// NaN is treated as silence
+		if math.IsNaN(float64(v)) {
+			v = 0
+		}
+
+		s.ring[(s.start+s.count+i)%len(s.ring)] = min(max(v, -1), 1)
+	}
+
+	s.count += n
+	return n
+}
+
+func (s *pcmStream) queued() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.count
+}
+
+// refill tops the stream up to streamFillSamples from fill_audio, if the module exports it.
+// It's called by the game loop, and must not be called while the mixer is locked as the module may call back into Audio.
+func (s *pcmStream) refill() {
+	s.mut.Lock()
+	n := (streamFillSamples - s.count) &^ 1
+	active := s.active
+	s.mut.Unlock()
+
+	if !active || n <= 0 {
+		return
+	}
+
+	if cap(s.scratch) < n {
+		s.scratch = make([]float32, n)
+	}
+
+	samples := s.scratch[:n]
+	if brut.wasm.CallFillAudio(samples) {
+		s.push(samples)
+	}
+}
+
+// readFrame never ends, the stream plays until its voice is stopped
+func (s *pcmStream) readFrame() ([2]float32, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.count < 2 {
+		return [2]float32{}, true
+	}
+
+	frame := [2]float32{s.ring[s.start], s.ring[s.start+1]}
+	s.start = (s.start + 2) % len(s.ring)
+	s.count -= 2
+
+	return frame, true
+}
+
+// StartStream starts playing samples generated by the module on BusSfx.
+// Only one stream plays at a time, its voice is returned if it's already playing.
+func (a *Audio) StartStream() Voice {
+	if a.Playing(a.streamVoice) {
+		return a.streamVoice
+	}
+
+	a.streamVoice = a.addVoice(&voice{stream: newVoiceStream(&a.stream)}, BusSfx)
+	a.stream.setActive(true)
+
+	return a.streamVoice
+}
+
+// PushSamples queues interleaved stereo samples to be played by the stream.
+// It returns how many were queued, samples that don't fit are dropped.
+func (a *Audio) PushSamples(samples []float32) int32 {
+	return int32(a.stream.push(samples))
+}
+
+// QueuedSamples returns the number of pushed samples that haven't been played yet
+func (a *Audio) QueuedSamples() int32 {
+	return int32(a.stream.queued())
+}
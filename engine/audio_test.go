@@ -0,0 +1,239 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTone writes a wav of frames stereo samples that all have the given value
+func writeTone(t *testing.T, path string, frames int, value int16) {
+	t.Helper()
+
+	w, err := newWavWriter(path, audioSampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples := make([]byte, frames*4)
+	for i := 0; i < len(samples); i += 2 {
+		binary.LittleEndian.PutUint16(samples[i:], uint16(value))
+	}
+
+	_, err = w.Write(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAudioOut mixes a few ticks headless to --audio-out, changing the buses between them
+func TestAudioOut(t *testing.T) {
+	const ticksPerStep = 4
+
+	out := filepath.Join(t.TempDir(), "mix.wav")
+	startModule(t, newTestModule().bytes(), Config{AudioOut: out})
+
+	a := &brut.Audio
+	writeTone(t, filepath.Join(brut.Config.AssetRoot, "tone.wav"), 64, math.MaxInt16/4)
+
+	tone := a.LoadSound("tone.wav")
+	if tone == InvalidSound {
+		t.Fatal("unable to load tone.wav")
+	}
+
+	sfx := a.Play(tone, true)
+
+	steps := []struct {
+		name  string
+		apply func()
+		want  float32 // both channels of the last frame mixed
+	}{
+		{
+			name:  "bus volume",
+			apply: func() { a.SetBusVolume(BusSfx, 0.5) },
+			want:  0.125,
+		},
+		{
+			name:  "bus muted",
+			apply: func() { a.SetBusMuted(BusSfx, true) },
+			want:  0,
+		},
+		{
+			name: "ducked",
+			apply: func() {
+				a.Stop(sfx)
+
+				music := a.Play(tone, true)
+				a.SetVoiceBus(music, BusMusic)
+
+				dialog := a.Play(tone, true)
+				a.SetVoiceBus(dialog, BusVoice)
+
+				a.SetBusDucking(BusMusic, BusVoice, 0.5)
+			},
+			want: 0.25*0.5 + 0.25,
+		},
+	}
+
+	for _, step := range steps {
+		step.apply()
+
+		for i := 0; i < ticksPerStep; i += 1 {
+			err := brut.Update()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Finishes the wav, Teardown does nothing more when the test ends
+	a.Teardown()
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var header wavHeader
+	err = binary.Read(bytes.NewReader(data), binary.LittleEndian, &header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	framesPerTick := audioSampleRate / brut.Config.TickRate
+	size := uint32(len(steps) * ticksPerStep * framesPerTick * 4)
+
+	if string(header.Riff[:]) != "RIFF" || string(header.Wave[:]) != "WAVE" || string(header.Data[:]) != "data" {
+		t.Fatalf("expected a wav header, got %+v", header)
+	}
+
+	if header.SampleRate != audioSampleRate || header.Channels != 2 || header.BitsPerSample != 16 {
+		t.Errorf("expected 16-bit stereo at %d hz, got %+v", audioSampleRate, header)
+	}
+
+	if header.DataSize != size || header.RiffSize != size+36 || len(data) != int(size)+44 {
+		t.Fatalf("expected %d bytes of samples for %d ticks, the header has %d (riff %d) and the file %d",
+			size, len(steps)*ticksPerStep, header.DataSize, header.RiffSize, len(data)-44)
+	}
+
+	samples := data[44:]
+
+	for i, step := range steps {
+		last := ((i+1)*ticksPerStep*framesPerTick - 1) * 4
+
+		for ch := 0; ch < 2; ch += 1 {
+			got := float32(int16(binary.LittleEndian.Uint16(samples[last+ch*2:]))) / math.MaxInt16
+			if math.Abs(float64(got-step.want)) > 0.01 {
+				t.Errorf("%s: expected channel %d to end at %.3f, got %.3f", step.name, ch, step.want, got)
+			}
+		}
+	}
+}
+
+func TestBusLowPass(t *testing.T) {
+	var a Audio
+	a.mixer.setup(&a.stream)
+	a.SetBusLowPass(BusSfx, 1000)
+
+	samples := make([]float32, 2048)
+	for i := range samples {
+		samples[i] = 1
+	}
+
+	a.mixer.buses[BusSfx].process(samples)
+
+	// A step is smoothed rather than passed through, then settles on the input
+	if samples[0] <= 0 || samples[0] >= 0.5 {
+		t.Errorf("expected the first sample to be smoothed, got %f", samples[0])
+	}
+
+	for i := 2; i < len(samples); i += 1 {
+		if samples[i] < samples[i-2] {
+			t.Fatalf("expected the filter to rise steadily, sample %d fell from %f to %f", i, samples[i-2], samples[i])
+		}
+	}
+
+	if last := samples[len(samples)-1]; math.Abs(float64(last-1)) > 0.001 {
+		t.Errorf("expected the filter to settle on 1, got %f", last)
+	}
+}
+
+func TestBusReverb(t *testing.T) {
+	var a Audio
+	a.mixer.setup(&a.stream)
+	a.SetBusReverb(BusSfx, 0.5)
+
+	// An impulse on the left channel, followed by silence
+	samples := make([]float32, audioSampleRate)
+	samples[0] = 1
+
+	a.mixer.buses[BusSfx].process(samples)
+
+	if samples[0] != 0.5 {
+		t.Errorf("expected half of the impulse to be dry, got %f", samples[0])
+	}
+
+	// Nothing comes out of the reverb until the shortest comb filter has delayed it
+	var tail [2]float32
+	for i := 2; i < len(samples); i += 1 {
+		if i/2 < 1116 && samples[i] != 0 {
+			t.Fatalf("expected silence before the first echo, sample %d is %f", i, samples[i])
+		}
+
+		tail[i&1] = max(tail[i&1], float32(math.Abs(float64(samples[i]))))
+	}
+
+	if tail[0] == 0 || tail[1] != 0 {
+		t.Errorf("expected a tail on the left channel only, got peaks of %v", tail)
+	}
+}
+
+func TestReloadMusic(t *testing.T) {
+	startModule(t, newTestModule().bytes(), Config{})
+
+	a := &brut.Audio
+	path := filepath.Join(brut.Config.AssetRoot, "music.wav")
+
+	// mixed returns the first frame of the next few mixed
+	mixed := func() [2]float32 {
+		a.mixer.mut.Lock()
+		defer a.mixer.mut.Unlock()
+
+		out := a.mixer.render(8)
+		return [2]float32{out[0], out[1]}
+	}
+
+	writeTone(t, path, 64, math.MaxInt16/4)
+
+	music := a.LoadMusic("music.wav")
+	if music == InvalidSound {
+		t.Fatal("unable to load music")
+	}
+
+	v := a.Play(music, false)
+	a.SetPan(v, 0.5)
+
+	if got := mixed(); math.Abs(float64(got[1]-0.25)) > 0.01 {
+		t.Fatalf("expected the first version to play at 0.25, got %v", got)
+	}
+
+	writeTone(t, path, 64, math.MaxInt16/2)
+	a.reloadMusic(music)
+
+	if !a.Playing(v) {
+		t.Fatal("expected the voice to keep playing")
+	}
+
+	// Panned halfway right, so the left speaker is at half volume
+	if got := mixed(); math.Abs(float64(got[0]-0.25)) > 0.01 || math.Abs(float64(got[1]-0.5)) > 0.01 {
+		t.Fatalf("expected the new version to play at 0.25, 0.5, got %v", got)
+	}
+}
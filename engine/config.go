@@ -3,6 +3,29 @@ package engine
 type (
 	Config struct {
 		Engine EngineFlag
+
+		// Set by the launcher before Setup is called.
+		// The render target starts out the same size as the window.
+		Module                    string
+		AssetRoot                 string
+		WindowWidth, WindowHeight int
+		Fullscreen                bool
+		TickRate                  int
+
+		// Headless runs the module without a window, rendering into a software target.
+		// It's always set in builds made with the headless tag.
+		// Frames limits how many ticks are run before exiting (0 runs until the module exits).
+		Headless bool
+		Frames   int
+
+		// RecordInput and ReplayInput are paths to input recordings.
+		// Seed is given to Platform.Random (0 picks one at random).
+		RecordInput string
+		ReplayInput string
+		Seed        uint64
+
+		// AudioOut is a wav file the mix is written to when headless
+		AudioOut string
 	}
 	IConfig interface {
 		SetEngineFlags(flags EngineFlag)
@@ -28,6 +51,28 @@ func (*EngineFlag) Export() map[string]EngineFlag {
 
 func (c *Config) Setup() error {
 	c.Engine = EngineHotReload | EngineLogging
+
+	if !hasWindow && !c.Headless {
+		LogInfo("engine - built without a window, running headless")
+		c.Headless = true
+	}
+
+	if c.Module == "" {
+		c.Module = "game.wasm"
+	}
+
+	if c.WindowWidth <= 0 {
+		c.WindowWidth = 960
+	}
+
+	if c.WindowHeight <= 0 {
+		c.WindowHeight = 540
+	}
+
+	if c.TickRate <= 0 {
+		c.TickRate = 60
+	}
+
 	return nil
 }
 
@@ -2,11 +2,9 @@ package engine
 
 import (
 	"errors"
-	"strings"
 	"sync"
 
 	"github.com/fsnotify/fsnotify"
-	eb "github.com/hajimehoshi/ebiten/v2"
 	"github.com/pkg/profile"
 )
 
@@ -14,41 +12,60 @@ import (
 var brut BrutEngine
 
 type BrutEngine struct {
-	mut              sync.Mutex
 	needsToCallSetup bool
 	wasm             *WasmRuntime
 
+	// pendingReload is the newest module queued by the watcher, it's applied at the start of the next tick
+	mut           sync.Mutex
+	pendingReload []byte
+
+	// reloadError is shown over the game while the last reload failed
+	reloadError string
+
+	moduleWatcher *fsnotify.Watcher // nil unless hot reloading is enabled
+
 	Config   Config
 	Platform Platform
 	Input    Input
 	Asset    Asset
 	Graphics Graphics
+	Audio    Audio
 }
 
-func Setup() error {
+// Setup initializes every subsystem and loads the game module described by cfg.
+// Zero values in cfg are replaced with their defaults.
+func Setup(cfg Config) error {
 	p := profile.Start(profile.ProfilePath("."))
 	defer p.Stop()
 
 	// Init
 	{
+		brut.Config = cfg
+
+		err := brut.Config.Setup()
+		if err != nil {
+			return err
+		}
+
 		w, err := NewWasmRuntime(
-			"game.wasm",
+			brut.Config.Module,
 			&brut.Config,
 			&brut.Platform,
 			&brut.Input,
 			&brut.Asset,
 			&brut.Graphics,
+			&brut.Audio,
 		)
 
 		if err != nil {
 			return err
 		}
 
-		err = errors.Join(err, brut.Config.Setup())
 		err = errors.Join(err, brut.Platform.Setup())
 		err = errors.Join(err, brut.Input.Setup())
 		err = errors.Join(err, brut.Asset.Setup())
 		err = errors.Join(err, brut.Graphics.Setup())
+		err = errors.Join(err, brut.Audio.Setup())
 		if err != nil {
 			return err
 		}
@@ -62,31 +79,17 @@ func Setup() error {
 
 		cfg := brut.Config
 
-		if cfg.Engine&EngineLogging != 0 {
-			AddLogLevel(LevelAll)
-		} else {
-			AddLogLevel(LevelError)
+		// The launcher decides the log level, modules are only able to quiet it
+		if cfg.Engine&EngineLogging == 0 {
+			RemoveLogLevel(LevelDebug | LevelInfo | LevelWarn)
 		}
 
 		if cfg.Engine&EngineHotReload != 0 {
 			LogDebug("engine - hot reloading is enabled")
-
-			watcher, err := fsnotify.NewWatcher()
-			if err != nil {
-				LogWarn("engine - unable to setup watcher: %s", err)
-				goto setupEnd
-			}
-
-			err = watcher.Add("game.wasm")
-			if err != nil {
-				LogWarn("engine - unable to watch game.wasm: %s", err)
-				goto setupEnd
-			}
-
-			go brut.watchForChanges(watcher)
+			brut.watchModule()
+			brut.Asset.watchAssets()
 		}
 
-	setupEnd:
 		// Call user setup after configuration so all setup is done before the window opens
 		brut.wasm.CallSetup()
 	}
@@ -94,85 +97,61 @@ func Setup() error {
 	return nil
 }
 
+// errExit is returned by Update once the module has asked to exit
+var errExit = errors.New("exit requested")
+
 func Run() error {
-	opts := eb.RunGameOptions{
-		GraphicsLibrary:   eb.GraphicsLibraryAuto,
-		InitUnfocused:     false,
-		ScreenTransparent: false,
-		SkipTaskbar:       false,
+	if brut.Config.Headless {
+		return runHeadless()
 	}
 
-	if err := eb.RunGameWithOptions(&brut, &opts); err != nil && !errors.Is(err, eb.Termination) {
-		return err
+	return runWindow()
+}
+
+// runHeadless ticks the game module without opening a window.
+// Ticks are run back-to-back; modules still observe the configured tick rate through Platform.Tps.
+func runHeadless() error {
+	LogDebug("engine - running headless for %d frames", brut.Config.Frames)
+
+	for frame := 0; brut.Config.Frames <= 0 || frame < brut.Config.Frames; frame += 1 {
+		err := brut.Update()
+		if errors.Is(err, errExit) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		brut.wasm.CallRender()
 	}
 
 	return nil
 }
 
 func Teardown() {
+	brut.unwatchModule()
 	brut.wasm.Teardown()
+	brut.Input.Teardown()
+	brut.Asset.Teardown()
+	brut.Audio.Teardown()
 }
 
 func (b *BrutEngine) Update() error {
 	if b.Platform.ExitRequested {
-		return eb.Termination
+		return errExit
 	}
 
+	b.applyPendingReload()
+
 	if b.needsToCallSetup {
 		b.wasm.CallSetup()
 		b.needsToCallSetup = false
 	}
 
+	b.Asset.Update()
 	b.Input.Update()
 	b.wasm.CallUpdate()
+	b.Audio.Update()
 	return nil
 }
-
-func (b *BrutEngine) Draw(dest *eb.Image) {
-	if b.Graphics.Target != nil {
-		b.wasm.CallRender()
-		b.Graphics.Present(dest)
-	}
-}
-
-func (b *BrutEngine) Layout(dw, dh int) (rw, rh int) {
-	return b.Graphics.TargetWidth, b.Graphics.TargetHeight
-}
-
-func (b *BrutEngine) watchForChanges(watcher *fsnotify.Watcher) {
-	watchList := strings.Join(watcher.WatchList(), ", ")
-	LogDebug("engine - watching %s for changes", watchList)
-
-	for {
-		select {
-		case event, ok := <-watcher.Events:
-			if !ok {
-				continue
-			}
-
-			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) {
-				b.mut.Lock()
-
-				LogDebug("engine - reloading %q", event.Name)
-
-				needsSetup := b.Config.Engine&EngineSetupAfterReload != 0
-				err := b.wasm.Reload(!needsSetup)
-				if err != nil {
-					LogWarn("%s", err)
-				}
-
-				b.needsToCallSetup = needsSetup
-				b.mut.Unlock()
-			}
-
-		case err, ok := <-watcher.Errors:
-			if !ok {
-				continue
-			}
-
-			b.mut.Lock()
-			LogError("engine - %s", err)
-			b.mut.Unlock()
-		}
-	}
-}
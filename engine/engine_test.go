@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+var updateGolden = flag.Bool("update", false, "write rendered frames as the new golden images")
+
+// testdata is resolved before any test changes the working directory
+var testdata string
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	dir, err := filepath.Abs("testdata")
+	if err != nil {
+		panic(err)
+	}
+
+	testdata = dir
+	SetLogLevel(LevelWarn | LevelError)
+	os.Exit(m.Run())
+}
+
+// newTestModule returns a module whose config turns off hot reloading and logging; tests reload by hand
+func newTestModule() *testModule {
+	m := &testModule{}
+	setFlags := m.importFunc("ConfigSetEngineFlags", valueTypes(api.ValueTypeI32, 1))
+	m.export("config", nil, i32Const(0), call(setFlags))
+	return m
+}
+
+// startModule sets the engine up headless with module and the rest of cfg, it's torn down when the test ends.
+// The working directory is a temporary one for the duration of the test, as Setup writes a profile there.
+func startModule(t *testing.T, module []byte, cfg Config) {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "game.wasm")
+
+	err := os.WriteFile(path, module, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	brut = BrutEngine{}
+
+	cfg.Module = path
+	cfg.AssetRoot = dir
+	cfg.Headless = true
+
+	err = Setup(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(Teardown)
+}
+
+// runGolden runs module for cfg.Frames ticks and compares the last frame with testdata/golden/<name>.png.
+// With -update the golden image is rewritten instead.
+func runGolden(t *testing.T, name string, module []byte, cfg Config) {
+	t.Helper()
+
+	startModule(t, module, cfg)
+
+	err := Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame := Snapshot()
+	goldenPath := filepath.Join(testdata, "golden", name+".png")
+
+	if *updateGolden {
+		err = WritePNG(goldenPath, frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	want, err := ReadPNG(goldenPath)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+
+	mismatched, err := CompareImages(frame, want, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mismatched > 0 {
+		actualPath := strings.TrimSuffix(goldenPath, ".png") + ".actual.png"
+		_ = WritePNG(actualPath, frame)
+		t.Fatalf("%d pixels differ from %s (rendered frame written to %s)", mismatched, goldenPath, actualPath)
+	}
+}
+
+func TestGoldenShapes(t *testing.T) {
+	f32 := api.ValueTypeF32
+
+	m := newTestModule()
+	clear := m.importFunc("GraphicsClear", valueTypes(f32, 4))
+	rect := m.importFunc("GraphicsRectangle", append(valueTypes(f32, 8), api.ValueTypeI32))
+	circle := m.importFunc("GraphicsCircle", append(valueTypes(f32, 7), api.ValueTypeI32))
+
+	// mem[0] counts ticks
+	m.export("update", nil,
+		i32Const(0), i32Const(0), i32Load(0), i32Const(1), []byte{opI32Add}, i32Store(0),
+	)
+
+	// The first rectangle moves 4 pixels per tick, so the frame shows how many ticks were run
+	m.export("render", nil,
+		f32Consts(0.1, 0.1, 0.2, 1), call(clear),
+		i32Const(0), i32Load(0), []byte{opF32FromI}, f32Const(4), []byte{opF32Mul},
+		f32Consts(8, 16, 16, 1, 0.5, 0, 1), i32Const(0), call(rect),
+		f32Consts(8, 40, 48, 24, 0, 1, 0.5, 0.5), i32Const(1), call(rect),
+		f32Consts(100, 56, 20, 0.2, 0.6, 1, 1), i32Const(0), call(circle),
+		f32Consts(100, 56, 28, 1, 1, 1, 1), i32Const(1), call(circle),
+	)
+
+	runGolden(t, "shapes", m.bytes(), Config{
+		WindowWidth:  160,
+		WindowHeight: 96,
+		Frames:       10,
+		Seed:         1,
+	})
+}
@@ -0,0 +1,164 @@
+package engine
+
+import (
+	"math"
+)
+
+/*
+
+Gamepads are assigned to one of maxGamepads slots when they connect and keep
+that slot until they disconnect. Only gamepads with a standard layout are
+supported; buttons are named after their position on an Xbox controller.
+
+The gamepad range of InputEvent refers to the gamepad in slot 0 when given to
+Input.JustPressed/JustReleased/Held/HeldDuration, so single player games can
+treat it like any other input.
+
+*/
+
+const maxGamepads = 4
+
+type GamepadAxis uint32
+
+const (
+	GamepadAxisLeftX GamepadAxis = iota
+	GamepadAxisLeftY
+	GamepadAxisRightX
+	GamepadAxisRightY
+	GamepadAxisLeftTrigger
+	GamepadAxisRightTrigger
+	_gamepadAxisMax
+)
+
+func (*GamepadAxis) Export() map[string]GamepadAxis {
+	return map[string]GamepadAxis{
+		"LeftX":        GamepadAxisLeftX,
+		"LeftY":        GamepadAxisLeftY,
+		"RightX":       GamepadAxisRightX,
+		"RightY":       GamepadAxisRightY,
+		"LeftTrigger":  GamepadAxisLeftTrigger,
+		"RightTrigger": GamepadAxisRightTrigger,
+	}
+}
+
+const (
+	_gamepadButtonMax    = _inputGamepadEnd - _inputGamepadStart - 1
+	defaultStickDeadzone = 0.15
+)
+
+// gamepadState is the sampled state of a gamepad slot. Fields are exported so it can be recorded.
+type gamepadState struct {
+	Connected uint32
+	Buttons   [_gamepadButtonMax]inputState
+	Axes      [_gamepadAxisMax]float32
+}
+
+func (s *gamepadState) button(e InputEvent) (inputState, bool) {
+	if e <= _inputGamepadStart || e >= _inputGamepadEnd {
+		return 0, false
+	}
+
+	return s.Buttons[e-_inputGamepadStart-1], true
+}
+
+// gamepad returns the current and previous state of a slot
+func (i *Input) gamepad(pad int32) (this, last *gamepadState, ok bool) {
+	if pad < 0 || pad >= maxGamepads {
+		return nil, nil, false
+	}
+
+	return &i.gamepads[pad], &i.lastGamepads[pad], true
+}
+
+func (i *Input) GamepadConnected(pad int32) bool {
+	this, _, ok := i.gamepad(pad)
+	return ok && this.Connected != 0
+}
+
+func (i *Input) GamepadJustPressed(pad int32, e InputEvent) bool {
+	last, this := i.gamepadButton(pad, e)
+	return !last && this
+}
+
+func (i *Input) GamepadJustReleased(pad int32, e InputEvent) bool {
+	last, this := i.gamepadButton(pad, e)
+	return last && !this
+}
+
+func (i *Input) GamepadHeld(pad int32, e InputEvent) bool {
+	_, this := i.gamepadButton(pad, e)
+	return this
+}
+
+// GamepadHeldDuration returns the number of ticks a button has been held for, including this one
+func (i *Input) GamepadHeldDuration(pad int32, e InputEvent) int32 {
+	if _, _, ok := i.gamepad(pad); !ok || e <= _inputGamepadStart || e >= _inputGamepadEnd {
+		return 0
+	}
+
+	return i.gamepadHeld[pad][e-_inputGamepadStart-1]
+}
+
+func (i *Input) gamepadButton(pad int32, e InputEvent) (last, this bool) {
+	thisPad, lastPad, ok := i.gamepad(pad)
+	if !ok {
+		return false, false
+	}
+
+	thisState, ok := thisPad.button(e)
+	if !ok {
+		LogWarn("input - %d is not a gamepad button", e)
+		return false, false
+	}
+
+	lastState, _ := lastPad.button(e)
+	return lastState&stateDown != 0, thisState&stateDown != 0
+}
+
+// GamepadAxis returns the value of an axis with the deadzone applied.
+// Sticks range from -1 to 1 and triggers from 0 to 1.
+func (i *Input) GamepadAxis(pad int32, axis GamepadAxis) float32 {
+	this, _, ok := i.gamepad(pad)
+	if !ok || axis >= _gamepadAxisMax {
+		return 0
+	}
+
+	switch axis {
+	case GamepadAxisLeftX, GamepadAxisLeftY:
+		return applyStickDeadzone(this.Axes[GamepadAxisLeftX], this.Axes[GamepadAxisLeftY], axis == GamepadAxisLeftY, i.deadzone)
+	case GamepadAxisRightX, GamepadAxisRightY:
+		return applyStickDeadzone(this.Axes[GamepadAxisRightX], this.Axes[GamepadAxisRightY], axis == GamepadAxisRightY, i.deadzone)
+	default:
+		return applyDeadzone(this.Axes[axis], i.deadzone)
+	}
+}
+
+// SetGamepadDeadzone sets how far (0-1) an axis must move before it's reported
+func (i *Input) SetGamepadDeadzone(deadzone float32) {
+	i.deadzone = min(max(deadzone, 0), 0.99)
+}
+
+// applyStickDeadzone applies the deadzone to the distance a stick has moved so diagonals aren't cut off
+func applyStickDeadzone(x, y float32, vertical bool, deadzone float32) float32 {
+	v := x
+	if vertical {
+		v = y
+	}
+
+	length := float32(math.Hypot(float64(x), float64(y)))
+	if length <= deadzone {
+		return 0
+	}
+
+	// Rescale so values start at 0 just outside of the deadzone
+	scaled := min((length-deadzone)/(1-deadzone), 1)
+	return v / length * scaled
+}
+
+func applyDeadzone(v, deadzone float32) float32 {
+	if v <= deadzone {
+		return 0
+	}
+
+	return min((v-deadzone)/(1-deadzone), 1)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"go/format"
@@ -22,10 +23,15 @@ var interfaces = []interface{}{
 	(*engine.IInput)(nil),
 	(*engine.IGraphics)(nil),
 	(*engine.IAsset)(nil),
+	(*engine.IAudio)(nil),
 }
 
 var apiVersion = "0.0.1"
 
+// Slice arguments the engine writes to, by export name and argument index.
+// Only these are copied back into the module's memory after a call; other slices are read-only.
+var outArgs = map[string][]int{}
+
 var fileSkeleton = `package engine
 
 import (
@@ -46,6 +52,7 @@ func (a *{{ .Namespace }}) Expose(wasm *WasmRuntime) {
 }`
 
 var wrapperSkeleton = `
+{{ .Buffers -}}
 // Calls {{ .Namespace }}.{{ .GoName }}
 func {{ .WasmName }}(ctx context.Context, m api.Module, stack []WasmValue) {
 {{ .WasmArguments -}}
@@ -69,6 +76,7 @@ type (
 		Name string    `json:"name"`
 		Args []ApiType `json:"args"`
 		Rets []ApiType `json:"rets"`
+		Out  []int     `json:"out,omitempty"` // indices of slice arguments written back after the call
 	}
 	Enum struct {
 		Type   ApiType        `json:"type"`
@@ -81,6 +89,7 @@ const (
 	ApiUint  ApiType = "u32"
 	ApiInt   ApiType = "i32"
 	ApiFloat ApiType = "f32"
+	ApiByte  ApiType = "u8" // only valid as a slice element
 )
 
 var (
@@ -128,6 +137,17 @@ func goTypeToApi(t reflect.Type) ApiType {
 		return ApiFloat
 	case reflect.String:
 		return ApiType("string")
+	case reflect.Slice:
+		elem := t.Elem()
+		if elem.Kind() == reflect.Uint8 {
+			return "[]" + ApiByte
+		}
+
+		if binary.Size(reflect.New(elem).Elem().Interface()) <= 0 {
+			panic(fmt.Sprintf("slice element %s must have a fixed size", elem))
+		}
+
+		return "[]" + goTypeToApi(elem)
 	case reflect.Struct:
 		name := ApiType(t.Name())
 		_, ok := structTypes[name]
@@ -148,6 +168,41 @@ func goTypeToApi(t reflect.Type) ApiType {
 	}
 }
 
+// sliceElem returns the element type of a slice api type
+func sliceElem(t ApiType) (ApiType, bool) {
+	elem, isSlice := strings.CutPrefix(string(t), "[]")
+	return ApiType(elem), isSlice
+}
+
+// returnedByPointer reports if values of type t are written to memory rather than returned on the stack
+func returnedByPointer(t ApiType) bool {
+	_, isSlice := sliceElem(t)
+	_, isStruct := structTypes[t]
+	return isSlice || isStruct
+}
+
+// apiToGo returns the go type for an api type
+func apiToGo(t ApiType) string {
+	switch t {
+	case ApiInt:
+		return "int32"
+	case ApiUint:
+		return "uint32"
+	case ApiBool:
+		return "bool"
+	case ApiFloat:
+		return "float32"
+	case ApiByte:
+		return "byte"
+	default:
+		if elem, isSlice := sliceElem(t); isSlice {
+			return "[]" + apiToGo(elem)
+		}
+
+		return string(t)
+	}
+}
+
 func decodeFromStack(t ApiType) string {
 	switch t {
 	case ApiInt:
@@ -204,7 +259,9 @@ func normalizeType(t ApiType) string {
 	}
 }
 
-func wasmToGo(t ApiType, variable string) string {
+// wasmToGo converts wasm values to their go equivalent.
+// export is the name of the calling export, used to report invalid memory accesses.
+func wasmToGo(export string, t ApiType, variable string) string {
 	switch t {
 	case ApiInt:
 		return fmt.Sprintf("int32(%s)", variable)
@@ -215,8 +272,13 @@ func wasmToGo(t ApiType, variable string) string {
 	case ApiFloat:
 		return fmt.Sprintf("float32(%s)", variable)
 	case "string":
-		return fmt.Sprintf("readWasmString(m.Memory(), %[1]s_0, %[1]s_1)", variable)
+		return fmt.Sprintf("readWasmString(m.Memory(), %q, %[2]s_0, %[2]s_1)", export, variable)
 	default:
+		// Slices are read from memory before the call (see main)
+		if _, isSlice := sliceElem(t); isSlice {
+			return variable
+		}
+
 		st, isStruct := structTypes[t]
 		if isStruct {
 			buf := bytes.Buffer{}
@@ -224,7 +286,7 @@ func wasmToGo(t ApiType, variable string) string {
 			buf.WriteByte('{')
 
 			for i, c := range st {
-				buf.WriteString(wasmToGo(c, fmt.Sprintf("%s_%d", variable, i)))
+				buf.WriteString(wasmToGo(export, c, fmt.Sprintf("%s_%d", variable, i)))
 				if i < len(st)-1 {
 					buf.WriteString(", ")
 				}
@@ -250,6 +312,7 @@ func generateJsonApi(types []reflect.Type) (*Api, error) {
 	}
 
 	for _, typ := range types {
+		namespace := typ.Name()[1:]
 		funcs := make([]Function, 0)
 		for mi := 0; mi < typ.NumMethod(); mi += 1 {
 			var (
@@ -270,14 +333,25 @@ func generateJsonApi(types []reflect.Type) (*Api, error) {
 				rets = append(rets, goTypeToApi(ret))
 			}
 
+			out := outArgs[namespace+name]
+			for _, i := range out {
+				if i >= len(args) {
+					panic(fmt.Sprintf("%s%s has no argument %d to mark as out", namespace, name, i))
+				}
+
+				if _, isSlice := sliceElem(args[i]); !isSlice {
+					panic(fmt.Sprintf("%s%s argument %d is %s, only slices can be out arguments", namespace, name, i, args[i]))
+				}
+			}
+
 			funcs = append(funcs, Function{
 				Name: name,
 				Args: args,
 				Rets: rets,
+				Out:  out,
 			})
 		}
 
-		namespace := typ.Name()[1:]
 		jsonApi.Exports = append(jsonApi.Exports, Export{
 			Namespace: namespace,
 			Functions: funcs,
@@ -385,6 +459,7 @@ func main() {
 					WasmName      string
 					WasmArguments string
 					WasmReturns   string
+					Buffers       string
 				}
 
 				data.Namespace = export.Namespace
@@ -393,11 +468,29 @@ func main() {
 
 				{ // generate pulling wasm arguments from the stack
 					argBuf := bytes.Buffer{}
+					bufferBuf := bytes.Buffer{}
 					stackIdx := 0
 					for i, arg := range fn.Args {
 						name := fmt.Sprintf("arg%d", i)
 						comp, isStruct := structTypes[arg]
-						if isStruct {
+
+						// Slices are passed as a pointer/length pair and copied out of memory
+						if elem, isSlice := sliceElem(arg); isSlice {
+							fmt.Fprintf(&argBuf, "\t%s_0 := api.DecodeU32(stack[%d])\n", name, stackIdx)
+							fmt.Fprintf(&argBuf, "\t%s_1 := api.DecodeU32(stack[%d])\n", name, stackIdx+1)
+							stackIdx += 2
+
+							if elem == ApiByte {
+								// Bytes are a direct view of memory so they don't need to be copied
+								fmt.Fprintf(&argBuf, "\t%[1]s := readWasmBytes(m.Memory(), %[2]q, %[1]s_0, %[1]s_1)\n", name, export.Namespace+fn.Name)
+							} else {
+								// Calls into the module are serialized, so one buffer per argument can be reused across calls.
+								// The engine must not hold on to slice arguments after returning.
+								buffer := fmt.Sprintf("%s%s", data.WasmName, strings.ToUpper(name[:1])+name[1:])
+								fmt.Fprintf(&bufferBuf, "// Decode buffer for %s, reused across calls\nvar %s %s\n\n", name, buffer, apiToGo(arg))
+								fmt.Fprintf(&argBuf, "\t%[1]s := readWasmSlice(m.Memory(), %[2]q, %[1]s_0, %[1]s_1, &%[3]s)\n", name, export.Namespace+fn.Name, buffer)
+							}
+						} else if isStruct {
 							for ci, t := range comp {
 								fmt.Fprintf(&argBuf, "\t%s_%d := %s(stack[%d])\n", name, ci, decodeFromStack(t), stackIdx)
 								stackIdx += 1
@@ -408,7 +501,16 @@ func main() {
 						}
 					}
 
+					// Strings, slices, and structs are returned through pointers given after the arguments
+					for i, ret := range fn.Rets {
+						if returnedByPointer(ret) {
+							fmt.Fprintf(&argBuf, "\tout%d := api.DecodeU32(stack[%d])\n", i, stackIdx)
+							stackIdx += 1
+						}
+					}
+
 					data.WasmArguments = argBuf.String()
+					data.Buffers = bufferBuf.String()
 				}
 
 				{ // generate wasm to go conversions and calling into engine code
@@ -433,7 +535,7 @@ func main() {
 
 					for i, arg := range fn.Args {
 						callBuf.WriteString("\t\t")
-						callBuf.WriteString(wasmToGo(arg, fmt.Sprintf("arg%d", i)))
+						callBuf.WriteString(wasmToGo(export.Namespace+fn.Name, arg, fmt.Sprintf("arg%d", i)))
 						callBuf.WriteString(",\n")
 					}
 
@@ -450,14 +552,28 @@ func main() {
 					retBuf := bytes.Buffer{}
 					stackIdx := 0
 
+					// Copy out arguments back into memory so changes made by the engine are visible to the module.
+					// Bytes are a view of memory already.
+					for _, i := range fn.Out {
+						if elem, _ := sliceElem(fn.Args[i]); elem != ApiByte {
+							fmt.Fprintf(&retBuf, "\twriteWasmSlice(m.Memory(), %[2]q, arg%[1]d_0, arg%[1]d)\n", i, export.Namespace+fn.Name)
+						}
+					}
+
 					for i, ret := range fn.Rets {
-						comp, isStruct := structTypes[ret]
-						if isStruct {
-							for ci, t := range comp {
-								fmt.Fprintf(&retBuf, "\tstack[%d] = %s(%s(r%d))\n", stackIdx, encodeToStack(t), normalizeType(t), ci+i)
-								stackIdx += 1
-							}
-						} else {
+						exportName := export.Namespace + fn.Name
+
+						_, isSlice := sliceElem(ret)
+						_, isStruct := structTypes[ret]
+
+						switch {
+						case ret == "string":
+							fmt.Fprintf(&retBuf, "\treturnWasmString(ctx, m, %q, out%[2]d, r%[2]d)\n", exportName, i)
+						case isSlice:
+							fmt.Fprintf(&retBuf, "\treturnWasmSlice(ctx, m, %q, out%[2]d, r%[2]d)\n", exportName, i)
+						case isStruct:
+							fmt.Fprintf(&retBuf, "\treturnWasmStruct(m, %q, out%[2]d, r%[2]d)\n", exportName, i)
+						default:
 							fmt.Fprintf(&retBuf, "\tstack[%d] = %s(%s(r%d))\n", stackIdx, encodeToStack(ret), normalizeType(ret), i)
 							stackIdx += 1
 						}
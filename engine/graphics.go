@@ -1,11 +1,8 @@
 package engine
 
 import (
-	"errors"
-
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
-	"github.com/hajimehoshi/ebiten/v2/vector"
+	"fmt"
+	"image"
 )
 
 type IGraphics interface {
@@ -13,22 +10,84 @@ type IGraphics interface {
 	Clear(c Color)
 	Texture(tex Texture, x, y float32)
 	TextureEx(tex Texture, x, y, rot, sx, sy float32, c Color)
+	TextureRegion(tex Texture, src Rect, x, y float32)
+	TextureRegionEx(tex Texture, src Rect, x, y, rot, sx, sy float32, c Color)
+	Sprite(tex Texture, src Rect, x, y, ox, oy, rot, sx, sy float32, flip Flip, c Color)
 	Rectangle(x, y, w, h float32, c Color, line bool)
 	Circle(x, y, rad float32, c Color, line bool)
 	Text(str string, x, y float32)
+	Screenshot(path string) bool
+	Submit(cmds []DrawCommand)
 }
 
 type Graphics struct {
-	Target                    *ebiten.Image
 	TargetWidth, TargetHeight int
 
-	opts ebiten.DrawImageOptions
+	target renderTarget
+
+	// Where the render target was last presented on screen
+	presentScale       float64
+	presentX, presentY float64
 }
 
+type (
+	// renderTarget is what the Graphics api draws to. It's on the gpu when running
+	// with a window (see graphics_gpu.go) and in software when headless.
+	renderTarget interface {
+		fill(c Color)
+		drawImage(img textureImage, region image.Rectangle, geom transform, c Color)
+		rect(x, y, w, h float32, c Color, line bool)
+		circle(x, y, rad float32, c Color, line bool)
+		text(s string, x, y int)
+		snapshot() *image.RGBA
+		dispose()
+	}
+
+	// textureImage holds the pixels of a texture in the form the render target draws them
+	textureImage interface {
+		bounds() image.Rectangle
+		subImage(region image.Rectangle) textureImage
+		dispose()
+	}
+)
+
 type Color struct {
 	R, G, B, A float32
 }
 
+type Rect struct {
+	X, Y, W, H float32
+}
+
+type Flip uint32
+
+const (
+	FlipX Flip = 1 << iota
+	FlipY
+)
+
+func (*Flip) Export() map[string]Flip {
+	return map[string]Flip{
+		"X": FlipX,
+		"Y": FlipY,
+	}
+}
+
+// DrawCommand draws a texture, or the Source region of it, like Sprite.
+// An empty Source draws the entire texture.
+type DrawCommand struct {
+	Texture  Texture
+	X, Y     float32
+	OriginX  float32
+	OriginY  float32
+	Rotation float32
+	ScaleX   float32
+	ScaleY   float32
+	Flip     Flip
+	Color    Color
+	Source   Rect
+}
+
 func (c Color) RGBA() (r, g, b, a uint32) {
 	r = uint32(c.R * 255)
 	r |= r << 8
@@ -46,90 +105,216 @@ func (c Color) RGBA() (r, g, b, a uint32) {
 }
 
 func (g *Graphics) Setup() error {
-	g.TargetWidth = 960
-	g.TargetHeight = 540
+	// The render target starts out the same size as the window
+	g.TargetWidth = brut.Config.WindowWidth
+	g.TargetHeight = brut.Config.WindowHeight
 
-	g.Target = ebiten.NewImage(g.TargetWidth, g.TargetHeight)
-	if g.Target == nil {
-		return errors.New("graphics - unable to create render target ")
+	target, err := newRenderTarget(g.TargetWidth, g.TargetHeight)
+	if err != nil {
+		return fmt.Errorf("graphics - unable to create render target: %w", err)
 	}
 
+	g.target = target
 	return nil
 }
 
-func (g *Graphics) Present(screen *ebiten.Image) {
-	g.opts.GeoM.Reset()
-	g.opts.ColorScale.Reset()
-	g.opts.Blend.BlendOperationAlpha = ebiten.BlendOperationAdd
-	screen.DrawImage(g.Target, &g.opts)
+func newRenderTarget(w, h int) (renderTarget, error) {
+	if brut.Config.Headless {
+		return newSoftwareTarget(w, h), nil
+	}
+
+	return newGpuTarget(w, h)
+}
+
+// newTextureImage creates a texture's pixels in the form the render target draws them
+func newTextureImage(img image.Image) (textureImage, error) {
+	if brut.Config.Headless {
+		return softwareImage{toRGBA(img)}, nil
+	}
+
+	return newGpuImage(img)
+}
+
+// windowToTarget maps a position in the window to the render target
+func (g *Graphics) windowToTarget(x, y float64) (float32, float32) {
+	if g.presentScale == 0 {
+		return float32(x), float32(y)
+	}
+
+	return float32((x - g.presentX) / g.presentScale), float32((y - g.presentY) / g.presentScale)
 }
 
 func (g *Graphics) SetTargetSize(w, h int32) {
 	LogDebug("graphics - resizing render target")
 
-	if g.Target != nil {
-		g.Target.Dispose()
+	target, err := newRenderTarget(int(w), int(h))
+	if err != nil {
+		LogError("graphics - unable to resize render target to %d, %d: %s", w, h, err)
+		return
 	}
 
+	g.target.dispose()
+	g.target = target
 	g.TargetWidth = int(w)
 	g.TargetHeight = int(h)
-
-	g.Target = ebiten.NewImage(g.TargetWidth, g.TargetHeight)
-	if g.Target == nil {
-		LogError("graphics - unable to resize render target to %d, %d", w, h)
-	}
 }
 
 func (g *Graphics) Clear(c Color) {
-	g.Target.Fill(c)
+	g.target.fill(c)
 }
 
 func (g *Graphics) Texture(tex Texture, x, y float32) {
 	g.TextureEx(tex, x, y, 0, 1, 1, Color{R: 1, G: 1, B: 1, A: 1})
 }
 
+// TextureEx draws a texture with its top left corner at x, y, scaled and rotated around its centre
 func (g *Graphics) TextureEx(tex Texture, x, y, rot, sx, sy float32, c Color) {
-	handle, ok := brut.Asset.GetTextureData(tex)
+	data, ok := brut.Asset.getTexture(tex)
 	if !ok {
 		return
 	}
 
-	o := &g.opts
-	o.GeoM.Reset()
-	o.ColorScale.Reset()
+	size := data.size()
+	w, h := float32(size.X)/2, float32(size.Y)/2
+
+	g.drawTexture(&data, &DrawCommand{
+		Texture:  tex,
+		X:        x + w*sx,
+		Y:        y + h*sy,
+		OriginX:  w,
+		OriginY:  h,
+		Rotation: rot,
+		ScaleX:   sx,
+		ScaleY:   sy,
+		Color:    c,
+	})
+}
+
+func (g *Graphics) TextureRegion(tex Texture, src Rect, x, y float32) {
+	g.TextureRegionEx(tex, src, x, y, 0, 1, 1, Color{R: 1, G: 1, B: 1, A: 1})
+}
 
-	if rot != 0 {
-		bounds := handle.Bounds().Size()
-		o.GeoM.Translate(-float64(bounds.X)/2, -float64(bounds.Y)/2)
-		o.GeoM.Rotate(float64(rot))
+// TextureRegionEx draws the src area of a texture, in pixels relative to its top left corner.
+// Like TextureEx, the area's top left corner is at x, y and it's scaled and rotated around its centre.
+func (g *Graphics) TextureRegionEx(tex Texture, src Rect, x, y, rot, sx, sy float32, c Color) {
+	if src.W <= 0 || src.H <= 0 {
+		g.TextureEx(tex, x, y, rot, sx, sy, c)
+		return
 	}
 
-	o.GeoM.Translate(float64(x), float64(y))
-	o.GeoM.Scale(float64(sx), float64(sy))
-	o.ColorScale.Scale(c.R, c.G, c.B, 1)
-	o.ColorScale.ScaleAlpha(c.A)
+	w, h := src.W/2, src.H/2
+	g.Sprite(tex, src, x+w*sx, y+h*sy, w, h, rot, sx, sy, 0, c)
+}
+
+// Sprite draws the src area of a texture (or all of it when src is empty).
+// The texture is flipped in place, scaled and rotated around the origin (ox, oy), then moved so the origin is at x, y.
+// The origin is in pixels relative to the top left corner of src.
+func (g *Graphics) Sprite(tex Texture, src Rect, x, y, ox, oy, rot, sx, sy float32, flip Flip, c Color) {
+	data, ok := brut.Asset.getTexture(tex)
+	if !ok {
+		return
+	}
 
-	g.Target.DrawImage(handle, o)
+	g.drawTexture(&data, &DrawCommand{
+		Texture:  tex,
+		X:        x,
+		Y:        y,
+		OriginX:  ox,
+		OriginY:  oy,
+		Rotation: rot,
+		ScaleX:   sx,
+		ScaleY:   sy,
+		Flip:     flip,
+		Color:    c,
+		Source:   src,
+	})
 }
 
-func (g *Graphics) Text(s string, x, y float32) {
-	ebitenutil.DebugPrintAt(g.Target, s, int(x), int(y))
+// Submit draws every command in order with a single call from the module.
+func (g *Graphics) Submit(cmds []DrawCommand) {
+	var (
+		data textureData
+		last Texture
+		ok   bool
+	)
+
+	for i := range cmds {
+		cmd := &cmds[i]
+
+		// Commands are usually grouped by texture, so only look it up when it changes
+		if cmd.Texture != last || i == 0 {
+			data, ok = brut.Asset.getTexture(cmd.Texture)
+			last = cmd.Texture
+		}
+
+		if !ok {
+			continue
+		}
+
+		g.drawTexture(&data, cmd)
+	}
 }
 
-func (g *Graphics) Rectangle(x, y, w, h float32, c Color, line bool) {
-	if line {
-		vector.StrokeRect(g.Target, x, y, w, h, 1, c, false)
+// drawTexture draws a texture, or cmd.Source of it.
+// Trimmed atlas frames are drawn at their offset when drawn whole; sources are relative to the trimmed image.
+func (g *Graphics) drawTexture(data *textureData, cmd *DrawCommand) {
+	bounds := data.bounds()
+	region := bounds
+	size := data.size()
+
+	var geom transform
+
+	if cmd.Source.W > 0 && cmd.Source.H > 0 {
+		src := cmd.Source
+		region = image.Rect(int(src.X), int(src.Y), int(src.X+src.W), int(src.Y+src.H)).Add(bounds.Min).Intersect(bounds)
+		if region.Empty() {
+			return
+		}
+
+		size = region.Size()
 	} else {
-		vector.DrawFilledRect(g.Target, x, y, w, h, c, false)
+		geom.Translate(float64(data.offset.X), float64(data.offset.Y))
 	}
+
+	// Flipping mirrors the texture within its own bounds so the origin stays in the same place
+	if cmd.Flip != 0 {
+		fx, fy := 1.0, 1.0
+		tx, ty := 0.0, 0.0
+
+		if cmd.Flip&FlipX != 0 {
+			fx, tx = -1, float64(size.X)
+		}
+
+		if cmd.Flip&FlipY != 0 {
+			fy, ty = -1, float64(size.Y)
+		}
+
+		geom.Scale(fx, fy)
+		geom.Translate(tx, ty)
+	}
+
+	geom.Translate(-float64(cmd.OriginX), -float64(cmd.OriginY))
+	geom.Scale(float64(cmd.ScaleX), float64(cmd.ScaleY))
+
+	if cmd.Rotation != 0 {
+		geom.Rotate(float64(cmd.Rotation))
+	}
+
+	geom.Translate(float64(cmd.X), float64(cmd.Y))
+
+	g.target.drawImage(data.image, region, geom, cmd.Color)
+}
+
+func (g *Graphics) Text(s string, x, y float32) {
+	g.target.text(s, int(x), int(y))
+}
+
+func (g *Graphics) Rectangle(x, y, w, h float32, c Color, line bool) {
+	g.target.rect(x, y, w, h, c, line)
 }
 
 func (g *Graphics) Circle(x, y, rad float32, c Color, line bool) {
-	if line {
-		vector.StrokeCircle(g.Target, x, y, rad, 1, c, false)
-	} else {
-		vector.DrawFilledCircle(g.Target, x, y, rad, c, false)
-	}
+	g.target.circle(x, y, rad, c, line)
 }
 
 // Wasm api
@@ -0,0 +1,141 @@
+//go:build !headless
+
+package engine
+
+import (
+	"errors"
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+type (
+	// gpuTarget is the render target used when running with a window
+	gpuTarget struct {
+		image *ebiten.Image
+		opts  ebiten.DrawImageOptions
+	}
+
+	// gpuImage is a texture drawn by gpuTarget
+	gpuImage struct {
+		*ebiten.Image
+	}
+)
+
+func newGpuTarget(w, h int) (renderTarget, error) {
+	img := ebiten.NewImage(w, h)
+	if img == nil {
+		return nil, errors.New("unable to create image")
+	}
+
+	return &gpuTarget{image: img}, nil
+}
+
+func newGpuImage(img image.Image) (textureImage, error) {
+	handle := ebiten.NewImageFromImage(img)
+	if handle == nil {
+		return nil, errors.New("unable to create image")
+	}
+
+	return gpuImage{handle}, nil
+}
+
+// Present draws the render target as large as it fits on screen, centered, while keeping its aspect ratio
+func (g *Graphics) Present(screen *ebiten.Image) {
+	target := g.target.(*gpuTarget)
+
+	sw, sh := float64(screen.Bounds().Dx()), float64(screen.Bounds().Dy())
+	tw, th := float64(g.TargetWidth), float64(g.TargetHeight)
+
+	g.presentScale = min(sw/tw, sh/th)
+	g.presentX = (sw - tw*g.presentScale) / 2
+	g.presentY = (sh - th*g.presentScale) / 2
+
+	o := &target.opts
+	o.GeoM.Reset()
+	o.GeoM.Scale(g.presentScale, g.presentScale)
+	o.GeoM.Translate(g.presentX, g.presentY)
+	o.ColorScale.Reset()
+	o.Blend.BlendOperationAlpha = ebiten.BlendOperationAdd
+	screen.DrawImage(target.image, o)
+}
+
+// GetTextureData returns the image behind a texture. Textures don't have one when running headless.
+func (a *Asset) GetTextureData(tex Texture) (*ebiten.Image, bool) {
+	data, ok := a.loadedTextures[tex]
+	if !ok {
+		return nil, false
+	}
+
+	img, ok := data.image.(gpuImage)
+	return img.Image, ok
+}
+
+func (t *gpuTarget) fill(c Color) {
+	t.image.Fill(c)
+}
+
+func (t *gpuTarget) drawImage(img textureImage, region image.Rectangle, geom transform, c Color) {
+	src := img.(gpuImage).Image
+	if region != src.Bounds() {
+		src = src.SubImage(region).(*ebiten.Image)
+	}
+
+	o := &t.opts
+	o.GeoM.SetElement(0, 0, geom.a1+1)
+	o.GeoM.SetElement(0, 1, geom.b)
+	o.GeoM.SetElement(0, 2, geom.tx)
+	o.GeoM.SetElement(1, 0, geom.c)
+	o.GeoM.SetElement(1, 1, geom.d1+1)
+	o.GeoM.SetElement(1, 2, geom.ty)
+	o.ColorScale.Reset()
+	o.ColorScale.Scale(c.R, c.G, c.B, 1)
+	o.ColorScale.ScaleAlpha(c.A)
+
+	t.image.DrawImage(src, o)
+}
+
+func (t *gpuTarget) rect(x, y, w, h float32, c Color, line bool) {
+	if line {
+		vector.StrokeRect(t.image, x, y, w, h, 1, c, false)
+	} else {
+		vector.DrawFilledRect(t.image, x, y, w, h, c, false)
+	}
+}
+
+func (t *gpuTarget) circle(x, y, rad float32, c Color, line bool) {
+	if line {
+		vector.StrokeCircle(t.image, x, y, rad, 1, c, false)
+	} else {
+		vector.DrawFilledCircle(t.image, x, y, rad, c, false)
+	}
+}
+
+func (t *gpuTarget) text(s string, x, y int) {
+	ebitenutil.DebugPrintAt(t.image, s, x, y)
+}
+
+// snapshot reads the target back from the gpu, it must be called from within the game loop
+func (t *gpuTarget) snapshot() *image.RGBA {
+	img := image.NewRGBA(t.image.Bounds())
+	t.image.ReadPixels(img.Pix)
+	return img
+}
+
+func (t *gpuTarget) dispose() {
+	t.image.Dispose()
+}
+
+func (i gpuImage) bounds() image.Rectangle {
+	return i.Bounds()
+}
+
+func (i gpuImage) subImage(region image.Rectangle) textureImage {
+	return gpuImage{i.SubImage(region).(*ebiten.Image)}
+}
+
+func (i gpuImage) dispose() {
+	i.Dispose()
+}
@@ -0,0 +1,235 @@
+package engine
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+/*
+
+Software versions of the Graphics draw calls. These are used when running
+headless so no gpu (or window) is required. They favor simplicity over speed.
+
+*/
+
+type (
+	// softwareTarget is the render target used when running headless
+	softwareTarget struct {
+		*image.RGBA
+	}
+
+	// softwareImage is a texture drawn by softwareTarget
+	softwareImage struct {
+		*image.RGBA
+	}
+)
+
+func newSoftwareTarget(w, h int) *softwareTarget {
+	return &softwareTarget{image.NewRGBA(image.Rect(0, 0, w, h))}
+}
+
+func (t *softwareTarget) fill(c Color) {
+	softFill(t.RGBA, c)
+}
+
+func (t *softwareTarget) drawImage(img textureImage, region image.Rectangle, geom transform, c Color) {
+	src := img.(softwareImage).SubImage(region).(*image.RGBA)
+	softDrawImage(t.RGBA, src, geom, c)
+}
+
+func (t *softwareTarget) rect(x, y, w, h float32, c Color, line bool) {
+	softRect(t.RGBA, x, y, w, h, c, line)
+}
+
+func (t *softwareTarget) circle(x, y, rad float32, c Color, line bool) {
+	softCircle(t.RGBA, x, y, rad, c, line)
+}
+
+func (t *softwareTarget) text(s string, x, y int) {
+	softText(t.RGBA, s, x, y)
+}
+
+func (t *softwareTarget) snapshot() *image.RGBA {
+	img := image.NewRGBA(t.Bounds())
+	copy(img.Pix, t.Pix)
+	return img
+}
+
+func (*softwareTarget) dispose() {}
+
+func (i softwareImage) bounds() image.Rectangle {
+	return i.Bounds()
+}
+
+func (i softwareImage) subImage(region image.Rectangle) textureImage {
+	return softwareImage{i.SubImage(region).(*image.RGBA)}
+}
+
+func (softwareImage) dispose() {}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+func softFill(dst *image.RGBA, c Color) {
+	r, g, b, a := c.premultiplied()
+	px := [4]uint8{toU8(r), toU8(g), toU8(b), toU8(a)}
+
+	for i := 0; i < len(dst.Pix); i += 4 {
+		copy(dst.Pix[i:i+4], px[:])
+	}
+}
+
+func softDrawImage(dst, src *image.RGBA, geom transform, c Color) {
+	if !geom.IsInvertible() {
+		return
+	}
+
+	origin := src.Bounds().Min
+	size := src.Bounds().Size()
+
+	// Find the destination area covered by the transformed source
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [][2]float64{{0, 0}, {float64(size.X), 0}, {0, float64(size.Y)}, {float64(size.X), float64(size.Y)}} {
+		x, y := geom.Apply(corner[0], corner[1])
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+
+	area := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+	area = area.Intersect(dst.Bounds())
+
+	inverse := geom
+	inverse.Invert()
+
+	for y := area.Min.Y; y < area.Max.Y; y += 1 {
+		for x := area.Min.X; x < area.Max.X; x += 1 {
+			// Sample from the center of each pixel
+			fx, fy := inverse.Apply(float64(x)+0.5, float64(y)+0.5)
+			sx, sy := int(math.Floor(fx)), int(math.Floor(fy))
+			if sx < 0 || sy < 0 || sx >= size.X || sy >= size.Y {
+				continue
+			}
+
+			i := src.PixOffset(origin.X+sx, origin.Y+sy)
+			p := src.Pix[i : i+4 : i+4]
+
+			a := float32(p[3]) / 255 * c.A
+			blendPixel(dst, x, y,
+				float32(p[0])/255*c.R*c.A,
+				float32(p[1])/255*c.G*c.A,
+				float32(p[2])/255*c.B*c.A,
+				a,
+			)
+		}
+	}
+}
+
+func softRect(dst *image.RGBA, x, y, w, h float32, c Color, line bool) {
+	area := image.Rect(round(x), round(y), round(x+w), round(y+h))
+	if !line {
+		softFillRect(dst, area, c)
+		return
+	}
+
+	softFillRect(dst, image.Rect(area.Min.X, area.Min.Y, area.Max.X, area.Min.Y+1), c)
+	softFillRect(dst, image.Rect(area.Min.X, area.Max.Y-1, area.Max.X, area.Max.Y), c)
+	softFillRect(dst, image.Rect(area.Min.X, area.Min.Y+1, area.Min.X+1, area.Max.Y-1), c)
+	softFillRect(dst, image.Rect(area.Max.X-1, area.Min.Y+1, area.Max.X, area.Max.Y-1), c)
+}
+
+func softFillRect(dst *image.RGBA, area image.Rectangle, c Color) {
+	r, g, b, a := c.premultiplied()
+
+	area = area.Intersect(dst.Bounds())
+	for py := area.Min.Y; py < area.Max.Y; py += 1 {
+		for px := area.Min.X; px < area.Max.X; px += 1 {
+			blendPixel(dst, px, py, r, g, b, a)
+		}
+	}
+}
+
+func softCircle(dst *image.RGBA, x, y, rad float32, c Color, line bool) {
+	r, g, b, a := c.premultiplied()
+
+	area := image.Rect(round(x-rad-1), round(y-rad-1), round(x+rad+1), round(y+rad+1))
+	area = area.Intersect(dst.Bounds())
+
+	for py := area.Min.Y; py < area.Max.Y; py += 1 {
+		for px := area.Min.X; px < area.Max.X; px += 1 {
+			dx := float64(px) + 0.5 - float64(x)
+			dy := float64(py) + 0.5 - float64(y)
+			dist := math.Hypot(dx, dy)
+
+			inside := dist <= float64(rad)
+			if line {
+				inside = math.Abs(dist-float64(rad)) <= 0.5
+			}
+
+			if inside {
+				blendPixel(dst, px, py, r, g, b, a)
+			}
+		}
+	}
+}
+
+func softText(dst *image.RGBA, s string, x, y int) {
+	face := basicfont.Face7x13
+
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.White,
+		Face: face,
+		Dot:  fixed.P(x, y+face.Ascent),
+	}
+
+	d.DrawString(s)
+}
+
+// blendPixel draws a premultiplied color over the pixel at x, y
+func blendPixel(dst *image.RGBA, x, y int, r, g, b, a float32) {
+	if a <= 0 {
+		return
+	}
+
+	i := dst.PixOffset(x, y)
+	p := dst.Pix[i : i+4 : i+4]
+	inv := 1 - a
+
+	p[0] = toU8(r + float32(p[0])/255*inv)
+	p[1] = toU8(g + float32(p[1])/255*inv)
+	p[2] = toU8(b + float32(p[2])/255*inv)
+	p[3] = toU8(a + float32(p[3])/255*inv)
+}
+
+func (c Color) premultiplied() (r, g, b, a float32) {
+	return c.R * c.A, c.G * c.A, c.B * c.A, c.A
+}
+
+func toU8(f float32) uint8 {
+	switch {
+	case f <= 0:
+		return 0
+	case f >= 1:
+		return 255
+	default:
+		return uint8(f*255 + 0.5)
+	}
+}
+
+func round(f float32) int {
+	return int(math.Round(float64(f)))
+}
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestTextureExRotatesAboutCentre(t *testing.T) {
+	startModule(t, newTestModule().bytes(), Config{WindowWidth: 8, WindowHeight: 8})
+
+	red := color.RGBA{255, 0, 0, 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for i := 0; i < len(img.Pix); i += 4 {
+		copy(img.Pix[i:], []uint8{red.R, red.G, red.B, red.A})
+	}
+
+	err := WritePNG(filepath.Join(brut.Config.AssetRoot, "bar.png"), img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tex := brut.Asset.LoadTexture("bar.png")
+	if tex == InvalidTexture {
+		t.Fatal("unable to load bar.png")
+	}
+
+	white := Color{1, 1, 1, 1}
+
+	tests := []struct {
+		name string
+		draw func(g *Graphics)
+		at   image.Rectangle
+	}{
+		{name: "unrotated", draw: func(g *Graphics) { g.TextureEx(tex, 2, 3, 0, 1, 1, white) }, at: image.Rect(2, 3, 6, 5)},
+		{name: "half turn", draw: func(g *Graphics) { g.TextureEx(tex, 2, 3, math.Pi, 1, 1, white) }, at: image.Rect(2, 3, 6, 5)},
+		{name: "quarter turn", draw: func(g *Graphics) { g.TextureEx(tex, 2, 3, math.Pi/2, 1, 1, white) }, at: image.Rect(3, 2, 5, 6)},
+		{name: "scaled", draw: func(g *Graphics) { g.TextureEx(tex, 0, 0, 0, 2, 2, white) }, at: image.Rect(0, 0, 8, 4)},
+		{name: "region", draw: func(g *Graphics) { g.TextureRegionEx(tex, Rect{W: 2, H: 2}, 1, 1, math.Pi/2, 1, 1, white) }, at: image.Rect(1, 1, 3, 3)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := &brut.Graphics
+			g.Clear(Color{})
+			test.draw(g)
+
+			frame := g.Snapshot()
+			for y := 0; y < 8; y += 1 {
+				for x := 0; x < 8; x += 1 {
+					want := color.RGBA{}
+					if image.Pt(x, y).In(test.at) {
+						want = red
+					}
+
+					if got := frame.RGBAAt(x, y); got != want {
+						t.Fatalf("pixel %d,%d: expected %v, got %v", x, y, want, got)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+//go:build headless
+
+package engine
+
+import (
+	"errors"
+	"image"
+)
+
+/*
+
+Builds made with the headless tag leave out ebiten, which needs a display as
+soon as it's imported, so they can run in CI and on machines without a gpu.
+They always run headless; the functions below are never reached.
+
+*/
+
+const hasWindow = false
+
+var errNoWindow = errors.New("built without a window (headless tag)")
+
+type inputDevices struct{}
+
+func runWindow() error {
+	return errNoWindow
+}
+
+func newGpuTarget(w, h int) (renderTarget, error) {
+	return nil, errNoWindow
+}
+
+func newGpuImage(img image.Image) (textureImage, error) {
+	return nil, errNoWindow
+}
+
+func (p *Platform) setupWindow()  {}
+func (p *Platform) resizeWindow() {}
+func setWindowTitle(title string) {}
+func windowFps() float32          { return 0 }
+func windowTps() float32          { return 0 }
+
+func (i *Input) sampleDevices()     {}
+func setCursorMode(mode CursorMode) {}
@@ -1,21 +1,75 @@
 package engine
 
 import (
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"errors"
+	"io"
 )
 
 type (
 	Input struct {
-		cursorX, cursorY     float32
+		cursorX, cursorY     float32 // in render target coordinates
+		lastX, lastY         float32
+		cursorSampled        bool // the cursor has a position to measure movement from
+		wheelX, wheelY       float32
+		cursorMode           CursorMode
 		thisFrame, lastFrame [_inputMax + 1]inputState
+		held                 [_inputMax + 1]int32 // number of ticks each event has been down for
+		text                 []rune               // characters typed this tick
+		bindings             actionMap
+
+		touches, lastTouches [maxTouches]touchState
+		touchHeld            [maxTouches]int32
+		touchAsMouse         bool
+
+		gamepads, lastGamepads [maxGamepads]gamepadState
+		gamepadHeld            [maxGamepads][_gamepadButtonMax]int32
+		deadzone               float32
+
+		devices  inputDevices // live devices, see input_devices.go
+		recorder *inputRecorder
+		replay   *inputReplay
 	}
 	IInput interface {
-		Pressed(InputEvent) bool
-		Up(InputEvent) bool
-		Down(InputEvent) bool
+		JustPressed(InputEvent) bool
+		JustReleased(InputEvent) bool
+		Held(InputEvent) bool
+		HeldDuration(InputEvent) int32
+		ModifierDown(mod Modifier) bool
+		Repeated(InputEvent) bool
+		TextInput() string
+		BindAction(action string, e InputEvent)
+		BindAxis(axis string, negative, positive InputEvent)
+		BindGamepadAxis(axis string, gamepadAxis GamepadAxis)
+		ClearBindings(name string)
+		ActionJustPressed(action string) bool
+		ActionJustReleased(action string) bool
+		ActionHeld(action string) bool
+		AxisValue(axis string) float32
+		LoadBindings(path string) bool
+		SaveBindings(path string) bool
 		CursorX() float32
 		CursorY() float32
+		CursorDeltaX() float32
+		CursorDeltaY() float32
+		WheelX() float32
+		WheelY() float32
+		SetCursorMode(mode CursorMode)
+		GetCursorMode() CursorMode
+		TouchCount() int32
+		TouchID(index int32) int32
+		TouchX(id int32) float32
+		TouchY(id int32) float32
+		TouchJustBegan(id int32) bool
+		TouchJustEnded(id int32) bool
+		TouchDuration(id int32) int32
+		SetTouchAsMouse(enabled bool)
+		GamepadConnected(pad int32) bool
+		GamepadJustPressed(pad int32, e InputEvent) bool
+		GamepadJustReleased(pad int32, e InputEvent) bool
+		GamepadHeld(pad int32, e InputEvent) bool
+		GamepadHeldDuration(pad int32, e InputEvent) int32
+		GamepadAxis(pad int32, axis GamepadAxis) float32
+		SetGamepadDeadzone(deadzone float32)
 	}
 )
 
@@ -29,6 +83,111 @@ const (
 	InputEnter
 	InputSpace
 	InputBackspace
+	InputA
+	InputB
+	InputC
+	InputD
+	InputE
+	InputF
+	InputG
+	InputH
+	InputI
+	InputJ
+	InputK
+	InputL
+	InputM
+	InputN
+	InputO
+	InputP
+	InputQ
+	InputR
+	InputS
+	InputT
+	InputU
+	InputV
+	InputW
+	InputX
+	InputY
+	InputZ
+	InputAltLeft
+	InputAltRight
+	InputArrowDown
+	InputArrowLeft
+	InputArrowRight
+	InputArrowUp
+	InputBackquote
+	InputBackslash
+	InputBracketLeft
+	InputBracketRight
+	InputCapsLock
+	InputComma
+	InputContextMenu
+	InputControlLeft
+	InputControlRight
+	InputDelete
+	InputDigit0
+	InputDigit1
+	InputDigit2
+	InputDigit3
+	InputDigit4
+	InputDigit5
+	InputDigit6
+	InputDigit7
+	InputDigit8
+	InputDigit9
+	InputEnd
+	InputEqual
+	InputF1
+	InputF2
+	InputF3
+	InputF4
+	InputF5
+	InputF6
+	InputF7
+	InputF8
+	InputF9
+	InputF10
+	InputF11
+	InputF12
+	InputHome
+	InputInsert
+	InputMetaLeft
+	InputMetaRight
+	InputMinus
+	InputNumLock
+	InputNumpad0
+	InputNumpad1
+	InputNumpad2
+	InputNumpad3
+	InputNumpad4
+	InputNumpad5
+	InputNumpad6
+	InputNumpad7
+	InputNumpad8
+	InputNumpad9
+	InputNumpadAdd
+	InputNumpadDecimal
+	InputNumpadDivide
+	InputNumpadEnter
+	InputNumpadEqual
+	InputNumpadMultiply
+	InputNumpadSubtract
+	InputPageDown
+	InputPageUp
+	InputPause
+	InputPeriod
+	InputPrintScreen
+	InputQuote
+	InputScrollLock
+	InputSemicolon
+	InputShiftLeft
+	InputShiftRight
+	InputSlash
+	InputTab
+	InputAlt
+	InputControl
+	InputShift
+	InputMeta
 	_inputKeyboardEnd
 
 	_inputMouseStart
@@ -37,18 +196,196 @@ const (
 	InputMouseRight
 	_inputMouseEnd
 
+	_inputGamepadStart
+	InputGamepadA
+	InputGamepadB
+	InputGamepadX
+	InputGamepadY
+	InputGamepadLeftBumper
+	InputGamepadRightBumper
+	InputGamepadLeftTrigger
+	InputGamepadRightTrigger
+	InputGamepadBack
+	InputGamepadStart
+	InputGamepadGuide
+	InputGamepadLeftStick
+	InputGamepadRightStick
+	InputGamepadDpadUp
+	InputGamepadDpadDown
+	InputGamepadDpadLeft
+	InputGamepadDpadRight
+	_inputGamepadEnd
+
 	_inputMax
 )
 
 func (*InputEvent) Export() map[string]InputEvent {
 	return map[string]InputEvent{
-		"Escape":      InputEscape,
-		"Enter":       InputEnter,
-		"Space":       InputSpace,
-		"Backspace":   InputBackspace,
+		"Escape":         InputEscape,
+		"Enter":          InputEnter,
+		"Space":          InputSpace,
+		"Backspace":      InputBackspace,
+		"A":              InputA,
+		"B":              InputB,
+		"C":              InputC,
+		"D":              InputD,
+		"E":              InputE,
+		"F":              InputF,
+		"G":              InputG,
+		"H":              InputH,
+		"I":              InputI,
+		"J":              InputJ,
+		"K":              InputK,
+		"L":              InputL,
+		"M":              InputM,
+		"N":              InputN,
+		"O":              InputO,
+		"P":              InputP,
+		"Q":              InputQ,
+		"R":              InputR,
+		"S":              InputS,
+		"T":              InputT,
+		"U":              InputU,
+		"V":              InputV,
+		"W":              InputW,
+		"X":              InputX,
+		"Y":              InputY,
+		"Z":              InputZ,
+		"AltLeft":        InputAltLeft,
+		"AltRight":       InputAltRight,
+		"ArrowDown":      InputArrowDown,
+		"ArrowLeft":      InputArrowLeft,
+		"ArrowRight":     InputArrowRight,
+		"ArrowUp":        InputArrowUp,
+		"Backquote":      InputBackquote,
+		"Backslash":      InputBackslash,
+		"BracketLeft":    InputBracketLeft,
+		"BracketRight":   InputBracketRight,
+		"CapsLock":       InputCapsLock,
+		"Comma":          InputComma,
+		"ContextMenu":    InputContextMenu,
+		"ControlLeft":    InputControlLeft,
+		"ControlRight":   InputControlRight,
+		"Delete":         InputDelete,
+		"Digit0":         InputDigit0,
+		"Digit1":         InputDigit1,
+		"Digit2":         InputDigit2,
+		"Digit3":         InputDigit3,
+		"Digit4":         InputDigit4,
+		"Digit5":         InputDigit5,
+		"Digit6":         InputDigit6,
+		"Digit7":         InputDigit7,
+		"Digit8":         InputDigit8,
+		"Digit9":         InputDigit9,
+		"End":            InputEnd,
+		"Equal":          InputEqual,
+		"F1":             InputF1,
+		"F2":             InputF2,
+		"F3":             InputF3,
+		"F4":             InputF4,
+		"F5":             InputF5,
+		"F6":             InputF6,
+		"F7":             InputF7,
+		"F8":             InputF8,
+		"F9":             InputF9,
+		"F10":            InputF10,
+		"F11":            InputF11,
+		"F12":            InputF12,
+		"Home":           InputHome,
+		"Insert":         InputInsert,
+		"MetaLeft":       InputMetaLeft,
+		"MetaRight":      InputMetaRight,
+		"Minus":          InputMinus,
+		"NumLock":        InputNumLock,
+		"Numpad0":        InputNumpad0,
+		"Numpad1":        InputNumpad1,
+		"Numpad2":        InputNumpad2,
+		"Numpad3":        InputNumpad3,
+		"Numpad4":        InputNumpad4,
+		"Numpad5":        InputNumpad5,
+		"Numpad6":        InputNumpad6,
+		"Numpad7":        InputNumpad7,
+		"Numpad8":        InputNumpad8,
+		"Numpad9":        InputNumpad9,
+		"NumpadAdd":      InputNumpadAdd,
+		"NumpadDecimal":  InputNumpadDecimal,
+		"NumpadDivide":   InputNumpadDivide,
+		"NumpadEnter":    InputNumpadEnter,
+		"NumpadEqual":    InputNumpadEqual,
+		"NumpadMultiply": InputNumpadMultiply,
+		"NumpadSubtract": InputNumpadSubtract,
+		"PageDown":       InputPageDown,
+		"PageUp":         InputPageUp,
+		"Pause":          InputPause,
+		"Period":         InputPeriod,
+		"PrintScreen":    InputPrintScreen,
+		"Quote":          InputQuote,
+		"ScrollLock":     InputScrollLock,
+		"Semicolon":      InputSemicolon,
+		"ShiftLeft":      InputShiftLeft,
+		"ShiftRight":     InputShiftRight,
+		"Slash":          InputSlash,
+		"Tab":            InputTab,
+		"Alt":            InputAlt,
+		"Control":        InputControl,
+		"Shift":          InputShift,
+		"Meta":           InputMeta,
+
 		"MouseLeft":   InputMouseLeft,
 		"MouseMiddle": InputMouseMiddle,
 		"MouseRight":  InputMouseRight,
+
+		"GamepadA":            InputGamepadA,
+		"GamepadB":            InputGamepadB,
+		"GamepadX":            InputGamepadX,
+		"GamepadY":            InputGamepadY,
+		"GamepadLeftBumper":   InputGamepadLeftBumper,
+		"GamepadRightBumper":  InputGamepadRightBumper,
+		"GamepadLeftTrigger":  InputGamepadLeftTrigger,
+		"GamepadRightTrigger": InputGamepadRightTrigger,
+		"GamepadBack":         InputGamepadBack,
+		"GamepadStart":        InputGamepadStart,
+		"GamepadGuide":        InputGamepadGuide,
+		"GamepadLeftStick":    InputGamepadLeftStick,
+		"GamepadRightStick":   InputGamepadRightStick,
+		"GamepadDpadUp":       InputGamepadDpadUp,
+		"GamepadDpadDown":     InputGamepadDpadDown,
+		"GamepadDpadLeft":     InputGamepadDpadLeft,
+		"GamepadDpadRight":    InputGamepadDpadRight,
+	}
+}
+
+type Modifier uint32
+
+const (
+	ModifierControl Modifier = 1 << iota
+	ModifierShift
+	ModifierAlt
+	ModifierMeta
+)
+
+func (*Modifier) Export() map[string]Modifier {
+	return map[string]Modifier{
+		"Control": ModifierControl,
+		"Shift":   ModifierShift,
+		"Alt":     ModifierAlt,
+		"Meta":    ModifierMeta,
+	}
+}
+
+type CursorMode uint32
+
+const (
+	CursorVisible CursorMode = iota
+	CursorHidden
+	CursorCaptured // hidden and locked to the window, use CursorDeltaX/Y for movement
+)
+
+func (*CursorMode) Export() map[string]CursorMode {
+	return map[string]CursorMode{
+		"Visible":  CursorVisible,
+		"Hidden":   CursorHidden,
+		"Captured": CursorCaptured,
 	}
 }
 
@@ -59,28 +396,120 @@ const (
 	stateControl
 	stateShift
 	stateAlt
+	stateMeta
 )
 
+// modifierStates maps each Modifier to the state bit it's stored as
+var modifierStates = map[Modifier]inputState{
+	ModifierControl: stateControl,
+	ModifierShift:   stateShift,
+	ModifierAlt:     stateAlt,
+	ModifierMeta:    stateMeta,
+}
+
 func (i *Input) Setup() error {
+	cfg := brut.Config
+	i.deadzone = defaultStickDeadzone
+	i.bindings.setup()
+
+	if cfg.ReplayInput != "" {
+		replay, err := openInputReplay(cfg.ReplayInput)
+		if err != nil {
+			return err
+		}
+
+		LogInfo("input - replaying %q", cfg.ReplayInput)
+
+		// Modules must see the same random values they were recorded with
+		brut.Platform.setSeed(replay.seed)
+		i.replay = replay
+	}
+
+	if cfg.RecordInput != "" {
+		recorder, err := newInputRecorder(cfg.RecordInput, brut.Platform.seed)
+		if err != nil {
+			return err
+		}
+
+		LogInfo("input - recording to %q", cfg.RecordInput)
+		i.recorder = recorder
+	}
+
 	return nil
 }
 
-func (i *Input) Pressed(e InputEvent) bool {
-	last := i.lastFrame[e]&stateDown != 0
-	this := i.thisFrame[e]&stateDown != 0
-	return last && !this
+func (i *Input) Teardown() {
+	if i.recorder != nil {
+		err := i.recorder.Close()
+		if err != nil {
+			LogError("input - unable to finish recording: %s", err)
+		}
+
+		i.recorder = nil
+	}
+
+	if i.replay != nil {
+		_ = i.replay.Close()
+		i.replay = nil
+	}
 }
 
-func (i *Input) Up(e InputEvent) bool {
+func (i *Input) JustPressed(e InputEvent) bool {
 	last := i.lastFrame[e]&stateDown != 0
 	this := i.thisFrame[e]&stateDown != 0
-	return !last && !this
+	return !last && this
 }
 
-func (i *Input) Down(e InputEvent) bool {
+func (i *Input) JustReleased(e InputEvent) bool {
 	last := i.lastFrame[e]&stateDown != 0
 	this := i.thisFrame[e]&stateDown != 0
-	return last && this
+	return last && !this
+}
+
+func (i *Input) Held(e InputEvent) bool {
+	return i.thisFrame[e]&stateDown != 0
+}
+
+// HeldDuration returns the number of ticks an event has been held for, including this one
+func (i *Input) HeldDuration(e InputEvent) int32 {
+	return i.held[e]
+}
+
+// ModifierDown reports if every modifier in mod is held.
+// Modifiers are stored with every event, InputNone holds them on their own.
+func (i *Input) ModifierDown(mod Modifier) bool {
+	if mod == 0 {
+		return false
+	}
+
+	for m, state := range modifierStates {
+		if mod&m != 0 && i.thisFrame[InputNone]&state == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Repeated reports if an event was pressed this tick or is being held long enough to repeat, like a key in a text field
+func (i *Input) Repeated(e InputEvent) bool {
+	ticks := i.held[e]
+	if ticks == 1 {
+		return true
+	}
+
+	// Repeat after half a second, 30 times a second
+	rate := int32(brut.Config.TickRate)
+	delay := max(rate/2, 1)
+	interval := max(rate/30, 1)
+
+	return ticks > delay && (ticks-delay)%interval == 0
+}
+
+// TextInput returns the characters typed this tick as utf-8.
+// Characters are given after any input method has composed them, and never include control characters like backspace.
+func (i *Input) TextInput() string {
+	return string(i.text)
 }
 
 func (i *Input) CursorX() float32 {
@@ -91,55 +520,124 @@ func (i *Input) CursorY() float32 {
 	return i.cursorY
 }
 
+// CursorDeltaX returns how far the cursor moved horizontally this tick, in render target coordinates
+func (i *Input) CursorDeltaX() float32 {
+	return i.cursorX - i.lastX
+}
+
+// CursorDeltaY returns how far the cursor moved vertically this tick, in render target coordinates
+func (i *Input) CursorDeltaY() float32 {
+	return i.cursorY - i.lastY
+}
+
+// WheelX returns how far the mouse wheel (or a touchpad) scrolled horizontally this tick
+func (i *Input) WheelX() float32 {
+	return i.wheelX
+}
+
+// WheelY returns how far the mouse wheel scrolled this tick. Scrolling up is positive.
+func (i *Input) WheelY() float32 {
+	return i.wheelY
+}
+
+func (i *Input) SetCursorMode(mode CursorMode) {
+	if mode > CursorCaptured {
+		LogWarn("input - %d is not a valid cursor mode", mode)
+		return
+	}
+
+	i.cursorMode = mode
+
+	if brut.Config.Headless {
+		return
+	}
+
+	setCursorMode(mode)
+}
+
+func (i *Input) GetCursorMode() CursorMode {
+	return i.cursorMode
+}
+
 func (i *Input) Update() {
 	// Transfer/reset state
 	copy(i.lastFrame[:], i.thisFrame[:])
 	clear(i.thisFrame[:])
+	i.text = i.text[:0]
+
+	i.lastX, i.lastY = i.cursorX, i.cursorY
+	i.wheelX, i.wheelY = 0, 0
 
-	cx, cy := ebiten.CursorPosition()
-	i.cursorX = float32(cx)
-	i.cursorY = float32(cy)
+	i.lastGamepads = i.gamepads
+	clear(i.gamepads[:])
 
-	var modState inputState
+	i.lastTouches = i.touches
+	clear(i.touches[:])
 
-	if inpututil.KeyPressDuration(ebiten.KeyControl) >= 1 {
-		modState |= stateControl
+	switch {
+	case i.replay != nil:
+		i.readReplay()
+	case !brut.Config.Headless: // There are no devices to sample without a window
+		i.sampleDevices()
 	}
 
-	if inpututil.KeyPressDuration(ebiten.KeyShift) >= 1 {
-		modState |= stateShift
+	// The first position has nothing to move from, so it isn't reported as movement
+	if !i.cursorSampled {
+		i.lastX, i.lastY = i.cursorX, i.cursorY
+		i.cursorSampled = true
 	}
 
-	if inpututil.KeyPressDuration(ebiten.KeyAlt) >= 1 {
-		modState |= stateAlt
+	i.updateTouchDurations()
+
+	// The gamepad range of thisFrame mirrors the first gamepad
+	copy(i.thisFrame[_inputGamepadStart+1:_inputGamepadEnd], i.gamepads[0].Buttons[:])
+
+	for e, state := range i.thisFrame {
+		i.held[e] = heldFor(i.held[e], state)
 	}
 
-	for code, e := range eventMap {
-		var state inputState
+	for pad := range i.gamepads {
+		for b, state := range i.gamepads[pad].Buttons {
+			i.gamepadHeld[pad][b] = heldFor(i.gamepadHeld[pad][b], state)
+		}
+	}
 
-		switch {
-		case e >= _inputKeyboardStart && e <= _inputKeyboardEnd:
-			if inpututil.KeyPressDuration(ebiten.Key(code)) >= 1 {
-				state = stateDown
-			}
-		case e >= _inputMouseStart && e <= _inputMouseEnd:
-			if inpututil.MouseButtonPressDuration(ebiten.MouseButton(code)) >= 1 {
-				state = stateDown
-			}
+	if i.recorder != nil {
+		err := i.recorder.WriteFrame(i)
+		if err != nil {
+			LogError("input - unable to record frame: %s", err)
+			_ = i.recorder.Close()
+			i.recorder = nil
 		}
+	}
+}
 
-		i.thisFrame[e] = state | modState
+// heldFor returns the number of ticks an event has been down for after a tick in the given state
+func heldFor(ticks int32, state inputState) int32 {
+	if state&stateDown == 0 {
+		return 0
 	}
+
+	return ticks + 1
 }
 
-var eventMap = map[int]InputEvent{
-	int(ebiten.KeyEscape):    InputEscape,
-	int(ebiten.KeyBackspace): InputBackspace,
-	int(ebiten.KeySpace):     InputSpace,
+func (i *Input) readReplay() {
+	err := i.replay.ReadFrame(i)
+	if err == nil {
+		return
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		LogInfo("input - replay finished")
+	} else {
+		LogError("input - unable to read replay: %s", err)
+	}
+
+	_ = i.replay.Close()
+	i.replay = nil
 
-	int(ebiten.MouseButtonLeft):   InputMouseLeft,
-	int(ebiten.MouseButtonMiddle): InputMouseMiddle,
-	int(ebiten.MouseButtonRight):  InputMouseRight,
+	// The session is over once the recording runs out
+	brut.Platform.Exit()
 }
 
 var _ IInput = (*Input)(nil)
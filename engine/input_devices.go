@@ -0,0 +1,312 @@
+//go:build !headless
+
+package engine
+
+import (
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+/*
+
+Live devices are sampled through ebiten, so they're only available in builds
+with a window. Headless builds only receive input from replays.
+
+*/
+
+type (
+	// inputDevices holds what's needed to sample devices between ticks
+	inputDevices struct {
+		touchIDs     []ebiten.TouchID
+		touches      []touchState
+		gamepadIDs   []ebiten.GamepadID
+		gamepadSlots [maxGamepads]gamepadSlot
+	}
+
+	// gamepadSlot tracks which device is assigned to a slot
+	gamepadSlot struct {
+		id   ebiten.GamepadID
+		used bool
+	}
+)
+
+func (i *Input) sampleDevices() {
+	i.sample()
+	i.sampleGamepads()
+	i.sampleTouches()
+}
+
+func setCursorMode(mode CursorMode) {
+	switch mode {
+	case CursorVisible:
+		ebiten.SetCursorMode(ebiten.CursorModeVisible)
+	case CursorHidden:
+		ebiten.SetCursorMode(ebiten.CursorModeHidden)
+	case CursorCaptured:
+		ebiten.SetCursorMode(ebiten.CursorModeCaptured)
+	}
+}
+
+func (i *Input) sample() {
+	cx, cy := ebiten.CursorPosition()
+	i.cursorX, i.cursorY = brut.Graphics.windowToTarget(float64(cx), float64(cy))
+
+	wx, wy := ebiten.Wheel()
+	i.wheelX, i.wheelY = float32(wx), float32(wy)
+
+	var modState inputState
+
+	if inpututil.KeyPressDuration(ebiten.KeyControl) >= 1 {
+		modState |= stateControl
+	}
+
+	if inpututil.KeyPressDuration(ebiten.KeyShift) >= 1 {
+		modState |= stateShift
+	}
+
+	if inpututil.KeyPressDuration(ebiten.KeyAlt) >= 1 {
+		modState |= stateAlt
+	}
+
+	if inpututil.KeyPressDuration(ebiten.KeyMeta) >= 1 {
+		modState |= stateMeta
+	}
+
+	i.thisFrame[InputNone] = modState
+	i.text = ebiten.AppendInputChars(i.text)
+
+	for key, e := range keyMap {
+		var state inputState
+		if inpututil.KeyPressDuration(key) >= 1 {
+			state = stateDown
+		}
+
+		i.thisFrame[e] = state | modState
+	}
+
+	for button, e := range mouseMap {
+		var state inputState
+		if inpututil.MouseButtonPressDuration(button) >= 1 {
+			state = stateDown
+		}
+
+		i.thisFrame[e] = state | modState
+	}
+}
+
+// Keys and mouse buttons are mapped separately as their ebiten values overlap
+var keyMap = map[ebiten.Key]InputEvent{
+	ebiten.KeyEscape:         InputEscape,
+	ebiten.KeyEnter:          InputEnter,
+	ebiten.KeySpace:          InputSpace,
+	ebiten.KeyBackspace:      InputBackspace,
+	ebiten.KeyA:              InputA,
+	ebiten.KeyB:              InputB,
+	ebiten.KeyC:              InputC,
+	ebiten.KeyD:              InputD,
+	ebiten.KeyE:              InputE,
+	ebiten.KeyF:              InputF,
+	ebiten.KeyG:              InputG,
+	ebiten.KeyH:              InputH,
+	ebiten.KeyI:              InputI,
+	ebiten.KeyJ:              InputJ,
+	ebiten.KeyK:              InputK,
+	ebiten.KeyL:              InputL,
+	ebiten.KeyM:              InputM,
+	ebiten.KeyN:              InputN,
+	ebiten.KeyO:              InputO,
+	ebiten.KeyP:              InputP,
+	ebiten.KeyQ:              InputQ,
+	ebiten.KeyR:              InputR,
+	ebiten.KeyS:              InputS,
+	ebiten.KeyT:              InputT,
+	ebiten.KeyU:              InputU,
+	ebiten.KeyV:              InputV,
+	ebiten.KeyW:              InputW,
+	ebiten.KeyX:              InputX,
+	ebiten.KeyY:              InputY,
+	ebiten.KeyZ:              InputZ,
+	ebiten.KeyAltLeft:        InputAltLeft,
+	ebiten.KeyAltRight:       InputAltRight,
+	ebiten.KeyArrowDown:      InputArrowDown,
+	ebiten.KeyArrowLeft:      InputArrowLeft,
+	ebiten.KeyArrowRight:     InputArrowRight,
+	ebiten.KeyArrowUp:        InputArrowUp,
+	ebiten.KeyBackquote:      InputBackquote,
+	ebiten.KeyBackslash:      InputBackslash,
+	ebiten.KeyBracketLeft:    InputBracketLeft,
+	ebiten.KeyBracketRight:   InputBracketRight,
+	ebiten.KeyCapsLock:       InputCapsLock,
+	ebiten.KeyComma:          InputComma,
+	ebiten.KeyContextMenu:    InputContextMenu,
+	ebiten.KeyControlLeft:    InputControlLeft,
+	ebiten.KeyControlRight:   InputControlRight,
+	ebiten.KeyDelete:         InputDelete,
+	ebiten.KeyDigit0:         InputDigit0,
+	ebiten.KeyDigit1:         InputDigit1,
+	ebiten.KeyDigit2:         InputDigit2,
+	ebiten.KeyDigit3:         InputDigit3,
+	ebiten.KeyDigit4:         InputDigit4,
+	ebiten.KeyDigit5:         InputDigit5,
+	ebiten.KeyDigit6:         InputDigit6,
+	ebiten.KeyDigit7:         InputDigit7,
+	ebiten.KeyDigit8:         InputDigit8,
+	ebiten.KeyDigit9:         InputDigit9,
+	ebiten.KeyEnd:            InputEnd,
+	ebiten.KeyEqual:          InputEqual,
+	ebiten.KeyF1:             InputF1,
+	ebiten.KeyF2:             InputF2,
+	ebiten.KeyF3:             InputF3,
+	ebiten.KeyF4:             InputF4,
+	ebiten.KeyF5:             InputF5,
+	ebiten.KeyF6:             InputF6,
+	ebiten.KeyF7:             InputF7,
+	ebiten.KeyF8:             InputF8,
+	ebiten.KeyF9:             InputF9,
+	ebiten.KeyF10:            InputF10,
+	ebiten.KeyF11:            InputF11,
+	ebiten.KeyF12:            InputF12,
+	ebiten.KeyHome:           InputHome,
+	ebiten.KeyInsert:         InputInsert,
+	ebiten.KeyMetaLeft:       InputMetaLeft,
+	ebiten.KeyMetaRight:      InputMetaRight,
+	ebiten.KeyMinus:          InputMinus,
+	ebiten.KeyNumLock:        InputNumLock,
+	ebiten.KeyNumpad0:        InputNumpad0,
+	ebiten.KeyNumpad1:        InputNumpad1,
+	ebiten.KeyNumpad2:        InputNumpad2,
+	ebiten.KeyNumpad3:        InputNumpad3,
+	ebiten.KeyNumpad4:        InputNumpad4,
+	ebiten.KeyNumpad5:        InputNumpad5,
+	ebiten.KeyNumpad6:        InputNumpad6,
+	ebiten.KeyNumpad7:        InputNumpad7,
+	ebiten.KeyNumpad8:        InputNumpad8,
+	ebiten.KeyNumpad9:        InputNumpad9,
+	ebiten.KeyNumpadAdd:      InputNumpadAdd,
+	ebiten.KeyNumpadDecimal:  InputNumpadDecimal,
+	ebiten.KeyNumpadDivide:   InputNumpadDivide,
+	ebiten.KeyNumpadEnter:    InputNumpadEnter,
+	ebiten.KeyNumpadEqual:    InputNumpadEqual,
+	ebiten.KeyNumpadMultiply: InputNumpadMultiply,
+	ebiten.KeyNumpadSubtract: InputNumpadSubtract,
+	ebiten.KeyPageDown:       InputPageDown,
+	ebiten.KeyPageUp:         InputPageUp,
+	ebiten.KeyPause:          InputPause,
+	ebiten.KeyPeriod:         InputPeriod,
+	ebiten.KeyPrintScreen:    InputPrintScreen,
+	ebiten.KeyQuote:          InputQuote,
+	ebiten.KeyScrollLock:     InputScrollLock,
+	ebiten.KeySemicolon:      InputSemicolon,
+	ebiten.KeyShiftLeft:      InputShiftLeft,
+	ebiten.KeyShiftRight:     InputShiftRight,
+	ebiten.KeySlash:          InputSlash,
+	ebiten.KeyTab:            InputTab,
+	ebiten.KeyAlt:            InputAlt,
+	ebiten.KeyControl:        InputControl,
+	ebiten.KeyShift:          InputShift,
+	ebiten.KeyMeta:           InputMeta,
+}
+
+var mouseMap = map[ebiten.MouseButton]InputEvent{
+	ebiten.MouseButtonLeft:   InputMouseLeft,
+	ebiten.MouseButtonMiddle: InputMouseMiddle,
+	ebiten.MouseButtonRight:  InputMouseRight,
+}
+
+func (i *Input) sampleGamepads() {
+	d := &i.devices
+	d.gamepadIDs = ebiten.AppendGamepadIDs(d.gamepadIDs[:0])
+
+	// Free the slots of disconnected gamepads
+	for slot := range d.gamepadSlots {
+		s := &d.gamepadSlots[slot]
+		if s.used && !slices.Contains(d.gamepadIDs, s.id) {
+			LogInfo("input - gamepad %d disconnected", slot)
+			s.used = false
+		}
+	}
+
+	// Newly connected gamepads take the first free slot
+	for _, id := range d.gamepadIDs {
+		if !ebiten.IsStandardGamepadLayoutAvailable(id) {
+			continue
+		}
+
+		assigned := slices.ContainsFunc(d.gamepadSlots[:], func(s gamepadSlot) bool {
+			return s.used && s.id == id
+		})
+
+		if assigned {
+			continue
+		}
+
+		for slot := range d.gamepadSlots {
+			s := &d.gamepadSlots[slot]
+			if !s.used {
+				LogInfo("input - gamepad %d connected (%s)", slot, ebiten.GamepadName(id))
+				*s = gamepadSlot{id: id, used: true}
+				break
+			}
+		}
+	}
+
+	for slot, s := range d.gamepadSlots {
+		state := &i.gamepads[slot]
+		if !s.used {
+			continue
+		}
+
+		state.Connected = 1
+
+		for button, e := range gamepadButtonMap {
+			if ebiten.IsStandardGamepadButtonPressed(s.id, button) {
+				state.Buttons[e-_inputGamepadStart-1] = stateDown
+			}
+		}
+
+		state.Axes = [_gamepadAxisMax]float32{
+			GamepadAxisLeftX:        float32(ebiten.StandardGamepadAxisValue(s.id, ebiten.StandardGamepadAxisLeftStickHorizontal)),
+			GamepadAxisLeftY:        float32(ebiten.StandardGamepadAxisValue(s.id, ebiten.StandardGamepadAxisLeftStickVertical)),
+			GamepadAxisRightX:       float32(ebiten.StandardGamepadAxisValue(s.id, ebiten.StandardGamepadAxisRightStickHorizontal)),
+			GamepadAxisRightY:       float32(ebiten.StandardGamepadAxisValue(s.id, ebiten.StandardGamepadAxisRightStickVertical)),
+			GamepadAxisLeftTrigger:  float32(ebiten.StandardGamepadButtonValue(s.id, ebiten.StandardGamepadButtonFrontBottomLeft)),
+			GamepadAxisRightTrigger: float32(ebiten.StandardGamepadButtonValue(s.id, ebiten.StandardGamepadButtonFrontBottomRight)),
+		}
+	}
+}
+
+var gamepadButtonMap = map[ebiten.StandardGamepadButton]InputEvent{
+	ebiten.StandardGamepadButtonRightBottom:      InputGamepadA,
+	ebiten.StandardGamepadButtonRightRight:       InputGamepadB,
+	ebiten.StandardGamepadButtonRightLeft:        InputGamepadX,
+	ebiten.StandardGamepadButtonRightTop:         InputGamepadY,
+	ebiten.StandardGamepadButtonFrontTopLeft:     InputGamepadLeftBumper,
+	ebiten.StandardGamepadButtonFrontTopRight:    InputGamepadRightBumper,
+	ebiten.StandardGamepadButtonFrontBottomLeft:  InputGamepadLeftTrigger,
+	ebiten.StandardGamepadButtonFrontBottomRight: InputGamepadRightTrigger,
+	ebiten.StandardGamepadButtonCenterLeft:       InputGamepadBack,
+	ebiten.StandardGamepadButtonCenterRight:      InputGamepadStart,
+	ebiten.StandardGamepadButtonCenterCenter:     InputGamepadGuide,
+	ebiten.StandardGamepadButtonLeftStick:        InputGamepadLeftStick,
+	ebiten.StandardGamepadButtonRightStick:       InputGamepadRightStick,
+	ebiten.StandardGamepadButtonLeftTop:          InputGamepadDpadUp,
+	ebiten.StandardGamepadButtonLeftBottom:       InputGamepadDpadDown,
+	ebiten.StandardGamepadButtonLeftLeft:         InputGamepadDpadLeft,
+	ebiten.StandardGamepadButtonLeftRight:        InputGamepadDpadRight,
+}
+
+func (i *Input) sampleTouches() {
+	d := &i.devices
+	d.touchIDs = ebiten.AppendTouchIDs(d.touchIDs[:0])
+	d.touches = d.touches[:0]
+
+	for _, id := range d.touchIDs {
+		x, y := ebiten.TouchPosition(id)
+		tx, ty := brut.Graphics.windowToTarget(float64(x), float64(y))
+		d.touches = append(d.touches, touchState{Active: 1, ID: int32(id), X: tx, Y: ty})
+	}
+
+	i.setTouches(d.touches)
+}
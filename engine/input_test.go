@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCursorDeltaFromReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cursor.rec")
+
+	recorder, err := newInputRecorder(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	positions := [][2]float32{{40, 30}, {45, 28}, {45, 28}}
+	for _, pos := range positions {
+		err = recorder.WriteFrame(&Input{cursorX: pos[0], cursorY: pos[1]})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = recorder.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startModule(t, newTestModule().bytes(), Config{ReplayInput: path})
+
+	// The first tick starts where the cursor is rather than at 0, 0
+	expected := [][2]float32{{0, 0}, {5, -2}, {0, 0}}
+
+	for tick, want := range expected {
+		err = brut.Update()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := [2]float32{brut.Input.CursorDeltaX(), brut.Input.CursorDeltaY()}; got != want {
+			t.Errorf("tick %d: expected the cursor to move %v, got %v", tick, want, got)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package engine
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type LogLevel int
 
@@ -16,6 +19,10 @@ const (
 
 var logLevel LogLevel = LevelAll
 
+func SetLogLevel(l LogLevel) {
+	logLevel = l
+}
+
 func AddLogLevel(l LogLevel) {
 	logLevel |= l
 }
@@ -24,6 +31,25 @@ func RemoveLogLevel(l LogLevel) {
 	logLevel &= ^l
 }
 
+// ParseLogLevel converts a level name (debug, info, warn, error, all, none) to a LogLevel.
+// The returned level includes every level more severe than the one given.
+func ParseLogLevel(name string) (LogLevel, error) {
+	switch strings.ToLower(name) {
+	case "all", "debug":
+		return LevelAll, nil
+	case "info":
+		return LevelInfo | LevelWarn | LevelError, nil
+	case "warn":
+		return LevelWarn | LevelError, nil
+	case "error":
+		return LevelError, nil
+	case "none":
+		return LevelNone, nil
+	default:
+		return LevelNone, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
 func LogDebug(f string, args ...any) {
 	if logLevel&LevelDebug == 0 {
 		return
@@ -0,0 +1,37 @@
+//go:build !headless
+
+package engine
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Size of a line drawn by ebitenutil.DebugPrint
+const (
+	overlayLineHeight = 16
+	overlayCharWidth  = 6
+	overlayPadding    = 4
+)
+
+var overlayBackground = color.RGBA{R: 96, A: 224}
+
+// drawOverlay draws a message over the top of the window, on top of the render target
+func drawOverlay(screen *ebiten.Image, msg string) {
+	lines := strings.Split(msg, "\n")
+
+	width := 0
+	for _, line := range lines {
+		width = max(width, len(line)*overlayCharWidth)
+	}
+
+	w := float32(width + overlayPadding*2)
+	h := float32(len(lines)*overlayLineHeight + overlayPadding*2)
+	vector.DrawFilledRect(screen, 0, 0, w, h, overlayBackground, false)
+
+	ebitenutil.DebugPrintAt(screen, msg, overlayPadding, overlayPadding)
+}
@@ -2,8 +2,8 @@ package engine
 
 import (
 	"fmt"
-
-	"github.com/hajimehoshi/ebiten/v2"
+	"math/rand"
+	"time"
 )
 
 // IPlatform describes the public go api.
@@ -13,40 +13,63 @@ type IPlatform interface {
 	Log(msg string)
 	Fps() float32
 	Tps() float32
+	Random() float32
 	Exit()
+	ReloadCount() int32
 }
 
 type Platform struct {
 	ExitRequested             bool
 	ScreenWidth, ScreenHeight int
+
+	seed    uint64
+	rng     *rand.Rand
+	reloads int32 // successful hot reloads
 }
 
 func (p *Platform) Setup() error {
-	p.ScreenWidth = 960
-	p.ScreenHeight = 540
+	p.ScreenWidth = brut.Config.WindowWidth
+	p.ScreenHeight = brut.Config.WindowHeight
 	p.ExitRequested = false
 
-	ebiten.SetWindowSize(p.ScreenWidth, p.ScreenHeight)
-	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	seed := brut.Config.Seed
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+
+	p.setSeed(seed)
+
+	if !brut.Config.Headless {
+		p.setupWindow()
+	}
 
 	return nil
 }
 
+func (p *Platform) setSeed(seed uint64) {
+	LogDebug("platform - random seed is %d", seed)
+
+	p.seed = seed
+	p.rng = rand.New(rand.NewSource(int64(seed)))
+}
+
 func (p *Platform) SetTitle(title string) {
-	ebiten.SetWindowTitle(title)
+	if brut.Config.Headless {
+		return
+	}
+
+	setWindowTitle(title)
 }
 
 func (p *Platform) SetScreenSize(w, h int32) {
 	p.ScreenWidth = int(w)
 	p.ScreenHeight = int(h)
 
-	if ebiten.IsFullscreen() {
-		ebiten.SetFullscreen(false)
+	if brut.Config.Headless {
+		return
 	}
 
-	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeDisabled) // fixes issue with resizing
-	ebiten.SetWindowSize(p.ScreenWidth, p.ScreenHeight)
-	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	p.resizeWindow()
 }
 
 func (*Platform) Log(msg string) {
@@ -57,12 +80,31 @@ func (p *Platform) Exit() {
 	p.ExitRequested = true
 }
 
+// ReloadCount returns the number of times the module has been hot reloaded
+func (p *Platform) ReloadCount() int32 {
+	return p.reloads
+}
+
 func (*Platform) Fps() float32 {
-	return float32(ebiten.ActualFPS())
+	if brut.Config.Headless {
+		return float32(brut.Config.TickRate)
+	}
+
+	return windowFps()
 }
 
 func (*Platform) Tps() float32 {
-	return float32(ebiten.ActualTPS())
+	if brut.Config.Headless {
+		return float32(brut.Config.TickRate)
+	}
+
+	return windowTps()
+}
+
+// Random returns a number in [0, 1) from the engine's seeded generator.
+// Modules should prefer this over their own source so recorded sessions replay identically.
+func (p *Platform) Random() float32 {
+	return p.rng.Float32()
 }
 
 // Used to ensure Platform implements IPlatform correctly
@@ -0,0 +1,187 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+/*
+
+Input recordings store the state of every input event, gamepad, touch, the
+cursor, and typed text for each tick, along with the seed given to
+Platform.Random. Replaying a recording feeds those states back into Input in
+place of live devices, so a module sees the exact same session it was recorded
+with.
+
+*/
+
+const (
+	recordingMagic   = "BRUTREC\x00"
+	recordingVersion = 5
+
+	// Guards against corrupt recordings allocating huge amounts of memory
+	maxRecordedText = 1 << 16
+)
+
+type (
+	recordingHeader struct {
+		Magic   [8]byte
+		Version uint32
+		Events  uint32 // number of input states stored per frame
+		Seed    uint64
+	}
+	recordingFrame struct {
+		CursorX, CursorY float32
+		WheelX, WheelY   float32
+		States           [_inputMax + 1]inputState
+		Gamepads         [maxGamepads]gamepadState
+		Touches          [maxTouches]touchState
+	}
+
+	inputRecorder struct {
+		file *os.File
+		out  *bufio.Writer
+	}
+	inputReplay struct {
+		file *os.File
+		in   *bufio.Reader
+		seed uint64
+	}
+)
+
+func newInputRecorder(path string, seed uint64) (*inputRecorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &inputRecorder{
+		file: file,
+		out:  bufio.NewWriter(file),
+	}
+
+	header := recordingHeader{
+		Version: recordingVersion,
+		Events:  uint32(_inputMax + 1),
+		Seed:    seed,
+	}
+
+	copy(header.Magic[:], recordingMagic)
+
+	err = binary.Write(r.out, binary.LittleEndian, &header)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *inputRecorder) WriteFrame(i *Input) error {
+	frame := recordingFrame{
+		CursorX:  i.cursorX,
+		CursorY:  i.cursorY,
+		WheelX:   i.wheelX,
+		WheelY:   i.wheelY,
+		States:   i.thisFrame,
+		Gamepads: i.gamepads,
+		Touches:  i.touches,
+	}
+
+	err := binary.Write(r.out, binary.LittleEndian, &frame)
+	if err != nil {
+		return err
+	}
+
+	// Typed text follows the frame as a length and that many runes
+	err = binary.Write(r.out, binary.LittleEndian, uint32(len(i.text)))
+	if err != nil {
+		return err
+	}
+
+	return binary.Write(r.out, binary.LittleEndian, i.text)
+}
+
+func (r *inputRecorder) Close() error {
+	return errors.Join(r.out.Flush(), r.file.Close())
+}
+
+func openInputReplay(path string) (*inputReplay, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &inputReplay{
+		file: file,
+		in:   bufio.NewReader(file),
+	}
+
+	var header recordingHeader
+
+	err = binary.Read(r.in, binary.LittleEndian, &header)
+	if err == nil {
+		switch {
+		case string(header.Magic[:]) != recordingMagic:
+			err = fmt.Errorf("%s is not an input recording", path)
+		case header.Version != recordingVersion:
+			err = fmt.Errorf("%s has version %d, expected %d", path, header.Version, recordingVersion)
+		case header.Events != uint32(_inputMax+1):
+			err = fmt.Errorf("%s was recorded with a different set of input events", path)
+		}
+	}
+
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	r.seed = header.Seed
+	return r, nil
+}
+
+// ReadFrame replaces the current input state with the next recorded frame.
+// io.EOF is returned once every frame has been read.
+func (r *inputReplay) ReadFrame(i *Input) error {
+	var frame recordingFrame
+
+	err := binary.Read(r.in, binary.LittleEndian, &frame)
+	if err != nil {
+		return err
+	}
+
+	var length uint32
+
+	err = binary.Read(r.in, binary.LittleEndian, &length)
+	if err != nil {
+		return err
+	}
+
+	if length > maxRecordedText {
+		return fmt.Errorf("frame has %d typed characters, expected at most %d", length, maxRecordedText)
+	}
+
+	text := make([]rune, length)
+
+	err = binary.Read(r.in, binary.LittleEndian, text)
+	if err != nil {
+		return err
+	}
+
+	i.cursorX = frame.CursorX
+	i.cursorY = frame.CursorY
+	i.wheelX = frame.WheelX
+	i.wheelY = frame.WheelY
+	i.thisFrame = frame.States
+	i.gamepads = frame.Gamepads
+	i.touches = frame.Touches
+	i.text = text
+	return nil
+}
+
+func (r *inputReplay) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+// Snapshot returns a copy of the render target's current contents.
+// When running with a window this must be called from within the game loop.
+func (g *Graphics) Snapshot() *image.RGBA {
+	return g.target.snapshot()
+}
+
+// Screenshot writes the render target to a png file at path, which is relative to the asset root.
+// Paths that would escape the asset root are rejected, as path comes from the module.
+func (g *Graphics) Screenshot(path string) bool {
+	if !filepath.IsLocal(path) {
+		LogError("graphics - unable to save screenshot %q: path must be inside the asset root", path)
+		return false
+	}
+
+	err := WritePNG(brut.Asset.resolvePath(path), g.Snapshot())
+	if err != nil {
+		LogError("graphics - unable to save screenshot %q: %s", path, err)
+		return false
+	}
+
+	LogDebug("graphics - saved screenshot %q", path)
+	return true
+}
+
+// Snapshot returns a copy of the engine's render target. See Graphics.Snapshot.
+func Snapshot() *image.RGBA {
+	return brut.Graphics.Snapshot()
+}
+
+func WritePNG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(png.Encode(file, img), file.Close())
+}
+
+func ReadPNG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+	return png.Decode(file)
+}
+
+// CompareImages counts the pixels in got that differ from want by more than tolerance in any channel.
+// Images of different sizes are an error.
+func CompareImages(got, want image.Image, tolerance uint8) (int, error) {
+	gotBounds, wantBounds := got.Bounds(), want.Bounds()
+	if gotBounds.Size() != wantBounds.Size() {
+		return 0, fmt.Errorf("image size %s does not match expected size %s", gotBounds.Size(), wantBounds.Size())
+	}
+
+	mismatched := 0
+
+	for y := 0; y < gotBounds.Dy(); y += 1 {
+		for x := 0; x < gotBounds.Dx(); x += 1 {
+			r1, g1, b1, a1 := got.At(gotBounds.Min.X+x, gotBounds.Min.Y+y).RGBA()
+			r2, g2, b2, a2 := want.At(wantBounds.Min.X+x, wantBounds.Min.Y+y).RGBA()
+
+			if channelDiff(r1, r2) > tolerance ||
+				channelDiff(g1, g2) > tolerance ||
+				channelDiff(b1, b2) > tolerance ||
+				channelDiff(a1, a2) > tolerance {
+				mismatched += 1
+			}
+		}
+	}
+
+	return mismatched, nil
+}
+
+// channelDiff returns the difference between two 16-bit color channels in 8-bit units
+func channelDiff(a, b uint32) uint8 {
+	a >>= 8
+	b >>= 8
+
+	if a > b {
+		return uint8(a - b)
+	}
+
+	return uint8(b - a)
+}
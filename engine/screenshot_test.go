@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareImages(t *testing.T) {
+	filled := func(r image.Rectangle, c color.RGBA) *image.RGBA {
+		img := image.NewRGBA(r)
+		for y := r.Min.Y; y < r.Max.Y; y += 1 {
+			for x := r.Min.X; x < r.Max.X; x += 1 {
+				img.SetRGBA(x, y, c)
+			}
+		}
+
+		return img
+	}
+
+	grey := color.RGBA{100, 100, 100, 255}
+	small := image.Rect(0, 0, 4, 3)
+
+	// Two pixels differ by 3 in one channel, one by 10 in alpha
+	changed := filled(small, grey)
+	changed.SetRGBA(0, 0, color.RGBA{103, 100, 100, 255})
+	changed.SetRGBA(1, 0, color.RGBA{100, 97, 100, 255})
+	changed.SetRGBA(3, 2, color.RGBA{100, 100, 100, 245})
+
+	tests := []struct {
+		name      string
+		got, want image.Image
+		tolerance uint8
+		expected  int
+		err       bool
+	}{
+		{name: "identical", got: filled(small, grey), want: filled(small, grey), expected: 0},
+		{name: "within tolerance", got: changed, want: filled(small, grey), tolerance: 10, expected: 0},
+		{name: "beyond tolerance", got: changed, want: filled(small, grey), tolerance: 2, expected: 3},
+		{name: "alpha only", got: changed, want: filled(small, grey), tolerance: 3, expected: 1},
+		{name: "size mismatch", got: filled(small, grey), want: filled(image.Rect(0, 0, 3, 4), grey), err: true},
+		{name: "offset bounds", got: changed, want: filled(small.Add(image.Pt(5, 7)), grey), tolerance: 3, expected: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mismatched, err := CompareImages(test.got, test.want, test.tolerance)
+			if test.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if mismatched != test.expected {
+				t.Errorf("expected %d mismatched pixels, got %d", test.expected, mismatched)
+			}
+		})
+	}
+}
+
+func TestScreenshotPath(t *testing.T) {
+	startModule(t, newTestModule().bytes(), Config{})
+
+	if !brut.Graphics.Screenshot("frame.png") {
+		t.Fatal("expected a screenshot inside the asset root to be saved")
+	}
+
+	_, err := ReadPNG(filepath.Join(brut.Config.AssetRoot, "frame.png"))
+	if err != nil {
+		t.Fatalf("expected the screenshot to be saved in the asset root: %v", err)
+	}
+
+	outside := t.TempDir()
+
+	for _, path := range []string{
+		"../frame.png",
+		"shots/../../frame.png",
+		filepath.Join(outside, "frame.png"),
+	} {
+		if brut.Graphics.Screenshot(path) {
+			t.Errorf("expected screenshot %q outside the asset root to be rejected", path)
+		}
+	}
+
+	for _, dir := range []string{filepath.Dir(brut.Config.AssetRoot), outside} {
+		_, err = os.Stat(filepath.Join(dir, "frame.png"))
+		if !os.IsNotExist(err) {
+			t.Errorf("expected no screenshot to be written to %s", dir)
+		}
+	}
+}
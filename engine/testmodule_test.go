@@ -0,0 +1,330 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+/*
+
+testModule assembles small wasm modules for tests, so they don't depend on a
+compiler for the guest. Modules export their memory (one page unless pages is
+set) and the functions given to export. Functions can only be imported from the
+engine ("env"), and parameters are the only locals.
+
+*/
+
+type testModule struct {
+	types    [][]byte
+	imports  [][]byte
+	exports  []testFunc
+	imported int
+
+	pages, maxPages uint32 // maxPages is unlimited when 0
+	hideMemory      bool   // don't export the memory
+}
+
+type testFunc struct {
+	name string
+	typ  int
+	body []byte
+}
+
+const (
+	opUnreachable = 0x00
+	opBlock       = 0x02
+	opLoop        = 0x03
+	opEnd         = 0x0b
+	opBr          = 0x0c
+	opBrIf        = 0x0d
+	opCall        = 0x10
+	opDrop        = 0x1a
+	opLocalGet    = 0x20
+	opLocalSet    = 0x21
+	opI32Load     = 0x28
+	opI32Store    = 0x36
+	opF32Store    = 0x38
+	opI32Const    = 0x41
+	opF32Const    = 0x43
+	opI32Eqz      = 0x45
+	opI32Eq       = 0x46
+	opI32Add      = 0x6a
+	opI32Sub      = 0x6b
+	opF32Mul      = 0x94
+	opF32FromI    = 0xb2 // f32.convert_i32_s
+
+	blockEmpty = 0x40 // type of blocks that don't produce a value
+)
+
+// importFunc imports an engine function and returns its index
+func (m *testModule) importFunc(name string, params []api.ValueType, results ...api.ValueType) uint32 {
+	imp := append(wasmName("env"), wasmName(name)...)
+	imp = append(imp, 0x00) // function
+	imp = appendUleb(imp, uint64(m.typeIndex(params, results)))
+
+	m.imports = append(m.imports, imp)
+	m.imported += 1
+	return uint32(m.imported - 1)
+}
+
+// export adds an exported function without parameters, body is its instructions without the final end
+func (m *testModule) export(name string, results []api.ValueType, body ...[]byte) {
+	m.exportFunc(name, nil, results, body...)
+}
+
+// exportFunc adds an exported function, its parameters are locals 0 and up
+func (m *testModule) exportFunc(name string, params, results []api.ValueType, body ...[]byte) {
+	m.exports = append(m.exports, testFunc{
+		name: name,
+		typ:  m.typeIndex(params, results),
+		body: bytes.Join(body, nil),
+	})
+}
+
+func (m *testModule) typeIndex(params, results []api.ValueType) int {
+	typ := []byte{0x60}
+	typ = appendUleb(typ, uint64(len(params)))
+	typ = append(typ, params...)
+	typ = appendUleb(typ, uint64(len(results)))
+	typ = append(typ, results...)
+
+	for i, existing := range m.types {
+		if bytes.Equal(existing, typ) {
+			return i
+		}
+	}
+
+	m.types = append(m.types, typ)
+	return len(m.types) - 1
+}
+
+func (m *testModule) bytes() []byte {
+	out := []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}
+
+	out = appendSection(out, 1, m.types)
+	out = appendSection(out, 2, m.imports)
+
+	var funcs, exports, code [][]byte
+	for i, fn := range m.exports {
+		funcs = append(funcs, appendUleb(nil, uint64(fn.typ)))
+
+		exp := append(wasmName(fn.name), 0x00) // function
+		exports = append(exports, appendUleb(exp, uint64(m.imported+i)))
+
+		body := append([]byte{0x00}, fn.body...) // no locals
+		body = append(body, opEnd)
+		code = append(code, append(appendUleb(nil, uint64(len(body))), body...))
+	}
+
+	if !m.hideMemory {
+		exports = append(exports, append(wasmName("memory"), 0x02, 0x00))
+	}
+
+	memory := appendUleb([]byte{0x00}, uint64(max(m.pages, 1)))
+	if m.maxPages > 0 {
+		memory = appendUleb([]byte{0x01}, uint64(max(m.pages, 1)))
+		memory = appendUleb(memory, uint64(m.maxPages))
+	}
+
+	out = appendSection(out, 3, funcs)
+	out = appendSection(out, 5, [][]byte{memory})
+	out = appendSection(out, 7, exports)
+	return appendSection(out, 10, code)
+}
+
+// appendSection appends a section holding a vector of entries
+func appendSection(out []byte, id byte, entries [][]byte) []byte {
+	contents := appendUleb(nil, uint64(len(entries)))
+	for _, e := range entries {
+		contents = append(contents, e...)
+	}
+
+	out = append(out, id)
+	out = appendUleb(out, uint64(len(contents)))
+	return append(out, contents...)
+}
+
+func wasmName(s string) []byte {
+	return append(appendUleb(nil, uint64(len(s))), s...)
+}
+
+func appendUleb(out []byte, v uint64) []byte {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+
+		if v == 0 {
+			return append(out, b)
+		}
+
+		out = append(out, b|0x80)
+	}
+}
+
+func appendSleb(out []byte, v int64) []byte {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+
+		out = append(out, b|0x80)
+	}
+}
+
+func i32Const(v int32) []byte {
+	return appendSleb([]byte{opI32Const}, int64(v))
+}
+
+func f32Const(v float32) []byte {
+	return binary.LittleEndian.AppendUint32([]byte{opF32Const}, math.Float32bits(v))
+}
+
+func f32Consts(vs ...float32) []byte {
+	var out []byte
+	for _, v := range vs {
+		out = append(out, f32Const(v)...)
+	}
+
+	return out
+}
+
+func call(fn uint32) []byte {
+	return appendUleb([]byte{opCall}, uint64(fn))
+}
+
+// i32Load and i32Store access memory at the address on the stack plus offset
+func i32Load(offset uint32) []byte {
+	return appendUleb([]byte{opI32Load, 0x02}, uint64(offset))
+}
+
+func i32Store(offset uint32) []byte {
+	return appendUleb([]byte{opI32Store, 0x02}, uint64(offset))
+}
+
+func f32Store(offset uint32) []byte {
+	return appendUleb([]byte{opF32Store, 0x02}, uint64(offset))
+}
+
+func localGet(i uint32) []byte {
+	return appendUleb([]byte{opLocalGet}, uint64(i))
+}
+
+func localSet(i uint32) []byte {
+	return appendUleb([]byte{opLocalSet}, uint64(i))
+}
+
+func valueTypes(t api.ValueType, n int) []api.ValueType {
+	return bytes.Repeat([]byte{t}, n)
+}
+
+// audioModule starts a stream in setup and pushes pushed to it, samples are staged at 2048.
+// Its fill_audio sets every sample it's asked for to fill, or traps when trap is set.
+func audioModule(pushed []float32, fill float32, trap bool) []byte {
+	i32 := api.ValueTypeI32
+
+	m := newTestModule()
+	start := m.importFunc("AudioStartStream", nil, i32)
+	push := m.importFunc("AudioPushSamples", valueTypes(i32, 2), i32)
+
+	setup := [][]byte{call(start), {opDrop}}
+	for i, v := range pushed {
+		setup = append(setup, i32Const(2048), f32Const(v), f32Store(uint32(i*4)))
+	}
+
+	setup = append(setup, i32Const(2048), i32Const(int32(len(pushed))), call(push), []byte{opDrop})
+	m.export("setup", nil, setup...)
+
+	// Allocations always get the same buffer, it's only expected to be asked for once
+	m.exportFunc("alloc", valueTypes(i32, 1), valueTypes(i32, 1), i32Const(4096))
+
+	if trap {
+		m.exportFunc("fill_audio", valueTypes(i32, 2), nil, []byte{opUnreachable})
+		return m.bytes()
+	}
+
+	// for ; frames != 0; frames -= 1, ptr += 8 { ptr[0], ptr[1] = fill, fill }
+	m.exportFunc("fill_audio", valueTypes(i32, 2), nil,
+		[]byte{opBlock, blockEmpty, opLoop, blockEmpty},
+		localGet(1), []byte{opI32Eqz, opBrIf, 1},
+		localGet(0), f32Const(fill), f32Store(0),
+		localGet(0), f32Const(fill), f32Store(4),
+		localGet(0), i32Const(8), []byte{opI32Add}, localSet(0),
+		localGet(1), i32Const(1), []byte{opI32Sub}, localSet(1),
+		[]byte{opBr, 0, opEnd, opEnd},
+	)
+
+	return m.bytes()
+}
+
+func TestPushSamples(t *testing.T) {
+	startModule(t, audioModule([]float32{0.5, -0.5, 2, float32(math.NaN())}, 0, true), Config{})
+
+	s := &brut.Audio.stream
+	if queued := s.queued(); queued != 4 {
+		t.Fatalf("expected 4 samples to be queued, got %d", queued)
+	}
+
+	// Samples are clamped and NaN is silence
+	for _, want := range [][2]float32{{0.5, -0.5}, {1, 0}} {
+		if got, _ := s.readFrame(); got != want {
+			t.Errorf("expected frame %v, got %v", want, got)
+		}
+	}
+}
+
+func TestFillAudio(t *testing.T) {
+	startModule(t, audioModule(nil, 0.25, false), Config{})
+
+	s := &brut.Audio.stream
+	s.refill()
+
+	if queued := s.queued(); queued != streamFillSamples {
+		t.Fatalf("expected fill_audio to queue %d samples, got %d", streamFillSamples, queued)
+	}
+
+	buffer := brut.wasm.audioPtr
+
+	for i := 0; i < 100; i += 1 {
+		if got, _ := s.readFrame(); got != [2]float32{0.25, 0.25} {
+			t.Fatalf("expected frames of 0.25, got %v", got)
+		}
+	}
+
+	// Only what was played is asked for again, in the same buffer
+	s.refill()
+
+	if queued := s.queued(); queued != streamFillSamples {
+		t.Errorf("expected the stream to be topped up to %d samples, got %d", streamFillSamples, queued)
+	}
+
+	if brut.wasm.audioPtr != buffer {
+		t.Errorf("expected the fill_audio buffer to be reused, it moved from %d to %d", buffer, brut.wasm.audioPtr)
+	}
+}
+
+func TestFillAudioTrap(t *testing.T) {
+	startModule(t, audioModule(nil, 0, true), Config{})
+
+	brut.Audio.stream.refill()
+
+	if brut.wasm.cbFillAudio != nil {
+		t.Error("expected a trap to disable fill_audio")
+	}
+
+	if queued := brut.Audio.stream.queued(); queued != 0 {
+		t.Errorf("expected nothing to be queued by a trapping fill_audio, got %d samples", queued)
+	}
+
+	// The game loop keeps going without it
+	err := brut.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
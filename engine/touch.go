@@ -0,0 +1,193 @@
+package engine
+
+/*
+
+Touches are identified by the id ebiten gives them, which stays the same for as
+long as the finger is down. Modules find the active touches with TouchCount and
+TouchID, then query the rest by id. A touch that ended this tick is no longer
+counted, but TouchJustEnded and its last position are still available for it.
+
+When touches are used as the mouse, the oldest touch presses MouseLeft and moves
+the cursor, so games written for a mouse work on touch screens unchanged. On the
+tick it's lifted the cursor stays where it ended.
+
+*/
+
+const maxTouches = 10
+
+// touchState is a touch sampled during a tick. Fields are exported so it can be recorded.
+type touchState struct {
+	Active uint32
+	ID     int32
+	X, Y   float32
+}
+
+// findTouch returns the index of the touch with the given id
+func findTouch(touches *[maxTouches]touchState, id int32) (int, bool) {
+	for i, t := range touches {
+		if t.Active != 0 && t.ID == id {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+func (i *Input) TouchCount() int32 {
+	var count int32
+	for _, t := range i.touches {
+		if t.Active != 0 {
+			count += 1
+		}
+	}
+
+	return count
+}
+
+// TouchID returns the id of an active touch, index is between 0 and TouchCount
+func (i *Input) TouchID(index int32) int32 {
+	for _, t := range i.touches {
+		if t.Active == 0 {
+			continue
+		}
+
+		if index == 0 {
+			return t.ID
+		}
+
+		index -= 1
+	}
+
+	return -1
+}
+
+// touch returns a touch that's active or ended this tick
+func (i *Input) touch(id int32) (touchState, bool) {
+	if idx, ok := findTouch(&i.touches, id); ok {
+		return i.touches[idx], true
+	}
+
+	if idx, ok := findTouch(&i.lastTouches, id); ok {
+		return i.lastTouches[idx], true
+	}
+
+	return touchState{}, false
+}
+
+// TouchX returns the position of a touch in render target coordinates
+func (i *Input) TouchX(id int32) float32 {
+	t, _ := i.touch(id)
+	return t.X
+}
+
+// TouchY returns the position of a touch in render target coordinates
+func (i *Input) TouchY(id int32) float32 {
+	t, _ := i.touch(id)
+	return t.Y
+}
+
+func (i *Input) TouchJustBegan(id int32) bool {
+	_, last := findTouch(&i.lastTouches, id)
+	_, this := findTouch(&i.touches, id)
+	return !last && this
+}
+
+func (i *Input) TouchJustEnded(id int32) bool {
+	_, last := findTouch(&i.lastTouches, id)
+	_, this := findTouch(&i.touches, id)
+	return last && !this
+}
+
+// TouchDuration returns the number of ticks a touch has been down for, including this one
+func (i *Input) TouchDuration(id int32) int32 {
+	idx, ok := findTouch(&i.touches, id)
+	if !ok {
+		return 0
+	}
+
+	return i.touchHeld[idx]
+}
+
+// SetTouchAsMouse sets if the oldest touch should act as MouseLeft and move the cursor
+func (i *Input) SetTouchAsMouse(enabled bool) {
+	i.touchAsMouse = enabled
+}
+
+// setTouches places the touches that are down this tick into slots
+func (i *Input) setTouches(active []touchState) {
+	var began []touchState
+
+	// Touches keep their slot for as long as they're active, new touches take whichever slots are left
+	for _, t := range active {
+		if idx, ok := findTouch(&i.lastTouches, t.ID); ok {
+			i.touches[idx] = t
+		} else {
+			began = append(began, t)
+		}
+	}
+
+	for _, t := range began {
+		for idx := range i.touches {
+			if i.touches[idx].Active == 0 {
+				i.touches[idx] = t
+				break
+			}
+		}
+	}
+
+	if !i.touchAsMouse {
+		return
+	}
+
+	// Once every touch is lifted the cursor is left where the last one ended, so it's released where it was pressed
+	if t, ok := i.oldestTouch(&i.touches); ok {
+		i.thisFrame[InputMouseLeft] |= stateDown
+		i.cursorX, i.cursorY = t.X, t.Y
+	} else if t, ok := i.oldestTouch(&i.lastTouches); ok {
+		i.cursorX, i.cursorY = t.X, t.Y
+	}
+}
+
+// oldestTouch returns the active touch that's been down the longest, lower slots win ties.
+// It's used while sampling, so touchHeld still holds the durations of lastTouches.
+func (i *Input) oldestTouch(touches *[maxTouches]touchState) (touchState, bool) {
+	var (
+		oldest  touchState
+		longest int32 = -1
+	)
+
+	for idx, t := range touches {
+		if t.Active == 0 {
+			continue
+		}
+
+		var held int32
+		if last := i.lastTouches[idx]; last.Active != 0 && last.ID == t.ID {
+			held = i.touchHeld[idx]
+		}
+
+		if held > longest {
+			oldest, longest = t, held
+		}
+	}
+
+	return oldest, longest >= 0
+}
+
+// updateTouchDurations counts how long each touch has been down for
+func (i *Input) updateTouchDurations() {
+	var held [maxTouches]int32
+
+	for idx, t := range i.touches {
+		if t.Active == 0 {
+			continue
+		}
+
+		held[idx] = 1
+		if last := i.lastTouches[idx]; last.Active != 0 && last.ID == t.ID {
+			held[idx] = i.touchHeld[idx] + 1
+		}
+	}
+
+	i.touchHeld = held
+}
@@ -0,0 +1,58 @@
+package engine
+
+import "testing"
+
+// tickTouches runs the touch part of Input.Update with the given touches down.
+// The cursor is moved away first, like sampling the mouse would.
+func tickTouches(i *Input, active ...touchState) {
+	copy(i.lastFrame[:], i.thisFrame[:])
+	clear(i.thisFrame[:])
+
+	i.lastTouches = i.touches
+	clear(i.touches[:])
+
+	i.cursorX, i.cursorY = -1, -1
+	i.setTouches(active)
+	i.updateTouchDurations()
+}
+
+func TestTouchAsMouse(t *testing.T) {
+	touch := func(id int32, pos float32) touchState {
+		return touchState{Active: 1, ID: id, X: pos, Y: pos}
+	}
+
+	var i Input
+	i.SetTouchAsMouse(true)
+
+	tests := []struct {
+		name     string
+		touches  []touchState
+		cursor   float32
+		down     bool
+		released bool
+	}{
+		{name: "first touch", touches: []touchState{touch(1, 10)}, cursor: 10, down: true},
+		{name: "second touch is ignored", touches: []touchState{touch(1, 11), touch(2, 50)}, cursor: 11, down: true},
+		// 3 takes the slot 1 left, the cursor keeps following the older touch
+		{name: "oldest rather than lowest slot", touches: []touchState{touch(2, 51), touch(3, 90)}, cursor: 51, down: true},
+		{name: "next oldest", touches: []touchState{touch(3, 92)}, cursor: 92, down: true},
+		{name: "released where it ended", cursor: 92, released: true},
+		{name: "no touches", cursor: -1},
+	}
+
+	for _, test := range tests {
+		tickTouches(&i, test.touches...)
+
+		if i.cursorX != test.cursor || i.cursorY != test.cursor {
+			t.Errorf("%s: expected the cursor at %v, got %v, %v", test.name, test.cursor, i.cursorX, i.cursorY)
+		}
+
+		if i.Held(InputMouseLeft) != test.down {
+			t.Errorf("%s: expected MouseLeft down to be %v", test.name, test.down)
+		}
+
+		if i.JustReleased(InputMouseLeft) != test.released {
+			t.Errorf("%s: expected MouseLeft just released to be %v", test.name, test.released)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"math"
+)
+
+// transform is a 2d affine matrix that works like ebiten.GeoM, but is also available in headless builds.
+// The zero value is the identity; a and d are stored minus one so that's the case.
+type transform struct {
+	a1, b, tx float64
+	c, d1, ty float64
+}
+
+func (t *transform) Translate(x, y float64) {
+	t.tx += x
+	t.ty += y
+}
+
+func (t *transform) Scale(x, y float64) {
+	a, d := t.a1+1, t.d1+1
+
+	t.a1, t.b, t.tx = a*x-1, t.b*x, t.tx*x
+	t.c, t.d1, t.ty = t.c*y, d*y-1, t.ty*y
+}
+
+// Rotate rotates by theta radians, clockwise on screen
+func (t *transform) Rotate(theta float64) {
+	sin, cos := math.Sincos(theta)
+	a, d := t.a1+1, t.d1+1
+
+	t.a1, t.b, t.tx, t.c, t.d1, t.ty =
+		cos*a-sin*t.c-1, cos*t.b-sin*d, cos*t.tx-sin*t.ty,
+		sin*a+cos*t.c, sin*t.b+cos*d-1, sin*t.tx+cos*t.ty
+}
+
+func (t *transform) Apply(x, y float64) (float64, float64) {
+	return (t.a1+1)*x + t.b*y + t.tx, t.c*x + (t.d1+1)*y + t.ty
+}
+
+func (t *transform) det() float64 {
+	return (t.a1+1)*(t.d1+1) - t.b*t.c
+}
+
+func (t *transform) IsInvertible() bool {
+	return t.det() != 0
+}
+
+func (t *transform) Invert() {
+	det := t.det()
+	if det == 0 {
+		return
+	}
+
+	a, d := t.a1+1, t.d1+1
+
+	t.a1, t.b, t.tx, t.c, t.d1, t.ty =
+		d/det-1, -t.b/det, (t.b*t.ty-d*t.tx)/det,
+		-t.c/det, a/det-1, (t.c*t.tx-a*t.ty)/det
+}
@@ -2,13 +2,18 @@
 package engine
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
+	"unsafe"
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
@@ -28,16 +33,20 @@ type (
 		mod  api.Module
 		host wazero.HostModuleBuilder
 
-		filename string
 		compiled wazero.CompiledModule
 
 		cbConfig,
 		cbSetup,
 		cbTeardown,
 		cbUpdate,
-		cbRender api.Function
+		cbRender,
+		cbFillAudio api.Function
 
-		stack []uint64
+		// mut serializes calls into the module
+		mut        sync.Mutex
+		stack      []uint64
+		audioStack []uint64
+		audioPtr   uint32 // buffer of streamFillSamples given to fill_audio, allocated by the module
 	}
 	WasmModule interface {
 		Expose(*WasmRuntime)
@@ -59,9 +68,9 @@ func NewWasmRuntime(filename string, modules ...WasmModule) (*WasmRuntime, error
 	}
 
 	var wasm = &WasmRuntime{
-		filename: filename,
-		stack:    make([]uint64, 16),
-		ctx:      context.Background(),
+		stack:      make([]uint64, 16),
+		audioStack: make([]uint64, 2),
+		ctx:        context.Background(),
 	}
 
 	wasm.rt = wazero.NewRuntime(wasm.ctx)
@@ -95,122 +104,270 @@ func NewWasmRuntime(filename string, modules ...WasmModule) (*WasmRuntime, error
 		return nil, err
 	}
 
-	// Allow lower/uppercase versions of callbacks
-	wasm.cbConfig = wasm.mod.ExportedFunction("config")
-	if wasm.cbConfig == nil {
-		wasm.cbConfig = wasm.mod.ExportedFunction("Config")
-	}
-
-	wasm.cbSetup = wasm.mod.ExportedFunction("setup")
-	if wasm.cbSetup == nil {
-		wasm.cbSetup = wasm.mod.ExportedFunction("Setup")
-	}
-
-	wasm.cbTeardown = wasm.mod.ExportedFunction("teardown")
-	if wasm.cbTeardown == nil {
-		wasm.cbTeardown = wasm.mod.ExportedFunction("Teardown")
-	}
-
-	wasm.cbUpdate = wasm.mod.ExportedFunction("update")
-	if wasm.cbUpdate == nil {
-		wasm.cbUpdate = wasm.mod.ExportedFunction("Update")
-	}
-
-	wasm.cbRender = wasm.mod.ExportedFunction("render")
-	if wasm.cbRender == nil {
-		wasm.cbRender = wasm.mod.ExportedFunction("Render")
-	}
+	wasm.loadCallbacks()
 
 	return wasm, nil
 }
 
 func (w *WasmRuntime) Teardown() {
 	w.CallTeardown()
+
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	w.cbFillAudio = nil
 	_ = w.mod.Close(w.ctx)
 	_ = w.compiled.Close(w.ctx)
 	_ = w.rt.Close(w.ctx)
 }
 
-func (w *WasmRuntime) Reload(transferMemory bool) error {
+// Reload instantiates src and swaps it in for the running module once it's been validated.
+// If keepState is set, the running module's state is given to the new module (see migrateState).
+// If anything fails the previous module is left running and the error is returned.
+func (w *WasmRuntime) Reload(src []byte, keepState bool) (err error) {
 	if w.compiled == nil {
 		return errors.New("attempt to reload module before it has been loaded")
 	}
 
-	src, err := os.ReadFile(w.filename)
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	// Both modules are alive until the new one is known to work, so it can't share a name with the old one
+	newMod, err := w.rt.InstantiateWithConfig(w.ctx, src, wazero.NewModuleConfig().WithName(""))
 	if err != nil {
-		return err
+		return fmt.Errorf("unable to instantiate new module: %w", err)
 	}
 
-	newMod, err := w.rt.Instantiate(w.ctx, src)
+	defer func() {
+		if err != nil {
+			_ = newMod.Close(w.ctx)
+		}
+	}()
+
+	err = w.validateReload(newMod)
 	if err != nil {
 		return err
 	}
 
-	// Hold on to old memory while we load the new module
-	var (
-		oldMemory []byte
-		oldSize   = w.mod.Memory().Size()
-	)
-
-	if transferMemory {
-		var ok bool
-
-		oldMemory, ok = w.mod.Memory().Read(0, oldSize)
-		if !ok {
-			return errors.New("unable to transfer old memory to new module")
+	if keepState {
+		err = w.migrateState(newMod)
+		if err != nil {
+			return err
 		}
 	}
 
-	err = w.mod.Close(w.ctx)
+	err = callReloadHook(w.ctx, newMod)
 	if err != nil {
-		LogWarn("wasm - unable to close original module: %s", err)
+		return err
+	}
+
+	// Nothing can fail from here on
+	closeErr := w.mod.Close(w.ctx)
+	if closeErr != nil {
+		LogWarn("wasm - unable to close original module: %s", closeErr)
 	}
 
 	w.mod = newMod
+	w.loadCallbacks()
 
-	if transferMemory {
-		// Grow new module to hold old memory
-		_, ok := w.mod.Memory().Grow(oldSize / 65536)
+	// The audio buffer belonged to the old module
+	w.audioPtr = 0
+
+	return nil
+}
+
+// validateReload checks that a new module exports its memory and every callback the running module does
+func (w *WasmRuntime) validateReload(newMod api.Module) error {
+	// Memory is nil only if the module has none, not if it isn't exported
+	if len(newMod.ExportedMemoryDefinitions()) == 0 {
+		return errors.New("new module doesn't export its memory")
+	}
+
+	for _, cb := range wasmCallbacks {
+		if exportedCallback(w.mod, cb) != nil && exportedCallback(newMod, cb) == nil {
+			return fmt.Errorf("new module is missing %q, which the running module exports", cb.name)
+		}
+	}
+
+	return nil
+}
+
+// transferWasmMemory copies the old module's memory into the new one, growing it if needed
+func transferWasmMemory(from, to api.Memory) error {
+	oldSize, newSize := from.Size(), to.Size()
+
+	if newSize < oldSize {
+		_, ok := to.Grow((oldSize - newSize + 65535) / 65536)
 		if !ok {
 			return errors.New("unable to resize new module memory")
 		}
+	}
 
-		// Finally give new module old memory
-		ok = w.mod.Memory().Write(0, oldMemory)
-		if !ok {
-			return errors.New("unable to transfer module memory")
+	data, ok := from.Read(0, oldSize)
+	if !ok || !to.Write(0, data) {
+		return errors.New("unable to transfer module memory")
+	}
+
+	return nil
+}
+
+// migrateState moves the running module's state into newMod. If the running module exports
+// serialize_state and newMod exports deserialize_state, the state is serialized by one and
+// deserialized by the other, otherwise linear memory is copied as is.
+//
+//	serialize_state() -> u32
+//
+// Returns a pointer to a {version u32, ptr u32, len u32} header describing the serialized state.
+//
+//	deserialize_state(version: u32, ptr: u32, len: u32) -> u32
+//
+// Is given a copy of the state in memory allocated with newMod's 'alloc', and returns 0 if it
+// can't use it (i.e. the version isn't supported), which rejects the reload.
+func (w *WasmRuntime) migrateState(newMod api.Module) error {
+	serialize := exportedCallback(w.mod, serializeCallback)
+	deserialize := exportedCallback(newMod, deserializeCallback)
+
+	if serialize == nil || deserialize == nil {
+		return transferWasmMemory(w.mod.Memory(), newMod.Memory())
+	}
+
+	version, state, err := serializeState(w.ctx, w.mod, serialize)
+	if err != nil {
+		return fmt.Errorf("serialize_state failed: %w", err)
+	}
+
+	LogDebug("wasm - migrating %d bytes of state (version %d)", len(state), version)
+
+	err = deserializeState(w.ctx, newMod, deserialize, version, state)
+	if err != nil {
+		return fmt.Errorf("deserialize_state failed: %w", err)
+	}
+
+	return nil
+}
+
+// serializeState calls serialize_state, returning the schema version and a copy of the state
+func serializeState(ctx context.Context, mod api.Module, serialize api.Function) (version uint32, state []byte, err error) {
+	res, err := serialize.Call(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if len(res) != 1 {
+		return 0, nil, errors.New("expected a pointer to the state header to be returned")
+	}
+
+	header, ok := mod.Memory().Read(api.DecodeU32(res[0]), 12)
+	if !ok {
+		return 0, nil, errors.New("state header is outside of memory")
+	}
+
+	version = binary.LittleEndian.Uint32(header[0:])
+	ptr := binary.LittleEndian.Uint32(header[4:])
+	size := binary.LittleEndian.Uint32(header[8:])
+
+	data, ok := mod.Memory().Read(ptr, size)
+	if !ok {
+		return 0, nil, fmt.Errorf("state of %d bytes at %d is outside of memory", size, ptr)
+	}
+
+	// The view is only valid until the module is closed
+	return version, bytes.Clone(data), nil
+}
+
+// deserializeState copies state into memory allocated by the module and calls deserialize_state
+func deserializeState(ctx context.Context, mod api.Module, deserialize api.Function, version uint32, state []byte) (err error) {
+	// allocWasm traps by panicking, as it's normally called by host functions
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
 		}
+	}()
+
+	ptr := allocWasm(ctx, mod, "deserialize_state", uint32(len(state)))
+	if !mod.Memory().Write(ptr, state) {
+		return fmt.Errorf("unable to write %d bytes of state at %d", len(state), ptr)
 	}
 
-	// Reload callbacks
-	w.cbConfig = w.mod.ExportedFunction("config")
-	if w.cbConfig == nil {
-		w.cbConfig = w.mod.ExportedFunction("Config")
+	res, err := deserialize.Call(ctx, api.EncodeU32(version), api.EncodeU32(ptr), api.EncodeU32(uint32(len(state))))
+	if err != nil {
+		return err
 	}
 
-	w.cbSetup = w.mod.ExportedFunction("setup")
-	if w.cbSetup == nil {
-		w.cbSetup = w.mod.ExportedFunction("Setup")
+	if len(res) > 0 && api.DecodeU32(res[0]) == 0 {
+		return fmt.Errorf("version %d was rejected", version)
 	}
 
-	w.cbTeardown = w.mod.ExportedFunction("teardown")
-	if w.cbTeardown == nil {
-		w.cbTeardown = w.mod.ExportedFunction("Teardown")
+	return nil
+}
+
+// callReloadHook calls the new module's on_reload export if it has one.
+// The hook can return 0 to reject the reload, i.e. if the state it was given isn't usable.
+func callReloadHook(ctx context.Context, newMod api.Module) error {
+	hook := exportedCallback(newMod, reloadCallback)
+	if hook == nil {
+		return nil
 	}
 
-	w.cbUpdate = w.mod.ExportedFunction("update")
-	if w.cbUpdate == nil {
-		w.cbUpdate = w.mod.ExportedFunction("Update")
+	res, err := hook.Call(ctx)
+	if err != nil {
+		return fmt.Errorf("on_reload failed: %w", err)
 	}
 
-	w.cbRender = w.mod.ExportedFunction("render")
-	if w.cbRender == nil {
-		w.cbRender = w.mod.ExportedFunction("Render")
+	if len(res) > 0 && api.DecodeU32(res[0]) == 0 {
+		return errors.New("on_reload rejected the new module")
 	}
 
 	return nil
 }
 
+type wasmCallback struct {
+	name, alt string
+}
+
+// Callbacks a module can export for the engine to call
+var (
+	configCallback      = wasmCallback{"config", "Config"}
+	setupCallback       = wasmCallback{"setup", "Setup"}
+	teardownCallback    = wasmCallback{"teardown", "Teardown"}
+	updateCallback      = wasmCallback{"update", "Update"}
+	renderCallback      = wasmCallback{"render", "Render"}
+	fillAudioCallback   = wasmCallback{"fill_audio", "FillAudio"}
+	reloadCallback      = wasmCallback{"on_reload", "OnReload"}
+	serializeCallback   = wasmCallback{"serialize_state", "SerializeState"}
+	deserializeCallback = wasmCallback{"deserialize_state", "DeserializeState"}
+
+	// wasmCallbacks are checked by validateReload. The reload callbacks aren't included as they're optional for each module.
+	wasmCallbacks = []wasmCallback{
+		configCallback,
+		setupCallback,
+		teardownCallback,
+		updateCallback,
+		renderCallback,
+		fillAudioCallback,
+	}
+)
+
+// exportedCallback returns a callback exported with either of its names, or nil
+func exportedCallback(mod api.Module, cb wasmCallback) api.Function {
+	fn := mod.ExportedFunction(cb.name)
+	if fn == nil {
+		fn = mod.ExportedFunction(cb.alt)
+	}
+
+	return fn
+}
+
+// loadCallbacks looks up the callbacks of the current module, allowing lower/uppercase versions
+func (w *WasmRuntime) loadCallbacks() {
+	w.cbConfig = exportedCallback(w.mod, configCallback)
+	w.cbSetup = exportedCallback(w.mod, setupCallback)
+	w.cbTeardown = exportedCallback(w.mod, teardownCallback)
+	w.cbUpdate = exportedCallback(w.mod, updateCallback)
+	w.cbRender = exportedCallback(w.mod, renderCallback)
+	w.cbFillAudio = exportedCallback(w.mod, fillAudioCallback)
+}
+
 func (w *WasmRuntime) ConvertAndExpose(exportName string, proc any, wrapper api.GoModuleFunc) {
 	t := reflect.TypeOf(proc)
 	if t.Kind() != reflect.Func {
@@ -232,7 +389,7 @@ func (w *WasmRuntime) ConvertAndExpose(exportName string, proc any, wrapper api.
 			return []WasmType{WasmI32}
 		case reflect.Uint32, reflect.Uint, reflect.Bool:
 			return []WasmType{WasmU32}
-		case reflect.String:
+		case reflect.String, reflect.Slice:
 			return []WasmType{WasmU32, WasmU32}
 		case reflect.Struct:
 			types := make([]WasmType, 0)
@@ -258,7 +415,13 @@ func (w *WasmRuntime) ConvertAndExpose(exportName string, proc any, wrapper api.
 	out := t.NumOut()
 	for i := 0; i < out; i += 1 {
 		ret := t.Out(i)
-		rets = append(rets, kindToType(ret)...)
+		switch ret.Kind() {
+		case reflect.String, reflect.Slice, reflect.Struct:
+			// Returned through a pointer given by the module
+			args = append(args, WasmU32)
+		default:
+			rets = append(rets, kindToType(ret)...)
+		}
 	}
 
 	LogDebug("wasm - export %s", exportName)
@@ -320,7 +483,50 @@ func (w *WasmRuntime) CallRender() {
 	w.invokeCallback(w.cbRender)
 }
 
+// CallFillAudio asks the module to write len(samples) interleaved stereo samples, at most streamFillSamples.
+// It returns false if the module doesn't export fill_audio or the call failed.
+func (w *WasmRuntime) CallFillAudio(samples []float32) (ok bool) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	if w.cbFillAudio == nil {
+		return false
+	}
+
+	// Errors stop fill_audio from being called until the module is reloaded, otherwise they'd be logged for every buffer
+	defer func() {
+		if r := recover(); r != nil {
+			LogError("wasm - fill_audio disabled until the module is reloaded: %v", r)
+			w.cbFillAudio = nil
+			ok = false
+		}
+	}()
+
+	if len(samples) > streamFillSamples {
+		panic(fmt.Errorf("fill_audio - %d samples were requested, at most %d fit in the buffer", len(samples), streamFillSamples))
+	}
+
+	// The buffer is only allocated once, it's as large as a request can be
+	if w.audioPtr == 0 {
+		w.audioPtr = allocWasm(w.ctx, w.mod, "fill_audio", streamFillSamples*4)
+	}
+
+	w.audioStack[0] = api.EncodeU32(w.audioPtr)
+	w.audioStack[1] = api.EncodeU32(uint32(len(samples) / 2))
+
+	err := w.cbFillAudio.CallWithStack(w.ctx, w.audioStack)
+	if err != nil {
+		panic(err)
+	}
+
+	readWasmSlice(w.mod.Memory(), "fill_audio", w.audioPtr, uint32(len(samples)), &samples)
+	return true
+}
+
 func (w *WasmRuntime) invokeCallback(cb api.Function) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
 	clear(w.stack)
 
 	err := cb.CallWithStack(w.ctx, w.stack)
@@ -329,14 +535,188 @@ func (w *WasmRuntime) invokeCallback(cb api.Function) {
 	}
 }
 
-func readWasmString(m api.Memory, offset, count uint32) string {
+// WasmBoundsError is the trap raised when a module passes a pointer/length pair
+// that falls outside of its linear memory. The guest's current call is aborted.
+type WasmBoundsError struct {
+	Export        string // engine export the module called
+	Offset, Count uint32
+	MemorySize    uint32
+}
+
+func (e *WasmBoundsError) Error() string {
+	return fmt.Sprintf("%s - invalid memory access of %d bytes at %d (memory size is %d)", e.Export, e.Count, e.Offset, e.MemorySize)
+}
+
+// readWasmBytes returns a view of count bytes at offset in the module's memory.
+// Out of bounds reads trap with a WasmBoundsError naming the export that was called.
+func readWasmBytes(m api.Memory, export string, offset, count uint32) []byte {
+	if m == nil {
+		panic(&WasmBoundsError{Export: export, Offset: offset, Count: count})
+	}
+
 	buf, ok := m.Read(offset, count)
 	if !ok {
-		LogError("invalid memory read of %d bytes at %d", count, offset)
-		return ""
+		panic(&WasmBoundsError{Export: export, Offset: offset, Count: count, MemorySize: m.Size()})
+	}
+
+	return buf
+}
+
+func readWasmString(m api.Memory, export string, offset, count uint32) string {
+	return string(readWasmBytes(m, export, offset, count))
+}
+
+// readWasmSlice copies count elements at offset out of the module's memory into buf, which is grown as needed.
+// Elements are expected to be tightly packed and little-endian.
+func readWasmSlice[T any](m api.Memory, export string, offset, count uint32, buf *[]T) []T {
+	var elem T
+
+	// Validate the range before allocating so a bad length can't exhaust host memory
+	size := uint64(count) * uint64(binary.Size(elem))
+	if size > math.MaxUint32 {
+		panic(&WasmBoundsError{Export: export, Offset: offset, Count: math.MaxUint32})
+	}
+
+	src := readWasmBytes(m, export, offset, uint32(size))
+
+	if uint32(cap(*buf)) < count {
+		*buf = make([]T, count)
+	}
+
+	s := (*buf)[:count]
+	if count == 0 {
+		return s
+	}
+
+	if hasWasmLayout(reflect.TypeOf(elem)) {
+		copy(unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(s))), size), src)
+		return s
+	}
+
+	err := binary.Read(bytes.NewReader(src), binary.LittleEndian, s)
+	if err != nil {
+		panic(fmt.Errorf("%s - unable to read slice: %w", export, err))
+	}
+
+	return s
+}
+
+// Wasm memory is always little-endian
+var littleEndianHost = binary.NativeEndian.Uint16([]byte{1, 0}) == 1
+
+// hasWasmLayout reports if values of t are stored in Go memory exactly as they are in Wasm memory.
+// Slices of these types are copied directly rather than decoded field by field.
+func hasWasmLayout(t reflect.Type) bool {
+	if !littleEndianHost {
+		return false
+	}
+
+	switch t.Kind() {
+	// 32-bit fields can't introduce padding
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		return true
+	case reflect.Array:
+		return hasWasmLayout(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i += 1 {
+			if !hasWasmLayout(t.Field(i).Type) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return false
+	}
+}
+
+// writeWasmSlice copies s back into the module's memory at offset
+func writeWasmSlice[T any](m api.Memory, export string, offset uint32, s []T) {
+	if len(s) == 0 {
+		return
+	}
+
+	buf := readWasmBytes(m, export, offset, uint32(binary.Size(s)))
+
+	if hasWasmLayout(reflect.TypeOf(s).Elem()) {
+		copy(buf, unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(s))), len(buf)))
+		return
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(buf))
+
+	err := binary.Write(&out, binary.LittleEndian, s)
+	if err != nil {
+		panic(fmt.Errorf("%s - unable to write slice: %w", export, err))
+	}
+
+	copy(buf, out.Bytes())
+}
+
+// returnWasmStruct writes v to the module's memory at out
+func returnWasmStruct[T any](m api.Module, export string, out uint32, v T) {
+	var buf bytes.Buffer
+
+	err := binary.Write(&buf, binary.LittleEndian, v)
+	if err != nil {
+		panic(fmt.Errorf("%s - unable to write struct: %w", export, err))
+	}
+
+	dst := readWasmBytes(m.Memory(), export, out, uint32(buf.Len()))
+	copy(dst, buf.Bytes())
+}
+
+// returnWasmSlice copies s into memory allocated by the module's 'alloc' export,
+// then writes its location and element count (two u32s) to the module's memory at out.
+func returnWasmSlice[T any](ctx context.Context, m api.Module, export string, out uint32, s []T) {
+	// Validate the destination before asking the module for memory
+	_ = readWasmBytes(m.Memory(), export, out, 8)
+
+	var ptr uint32
+
+	if len(s) > 0 {
+		var buf bytes.Buffer
+
+		err := binary.Write(&buf, binary.LittleEndian, s)
+		if err != nil {
+			panic(fmt.Errorf("%s - unable to write slice: %w", export, err))
+		}
+
+		ptr = allocWasm(ctx, m, export, uint32(buf.Len()))
+
+		dst := readWasmBytes(m.Memory(), export, ptr, uint32(buf.Len()))
+		copy(dst, buf.Bytes())
+	}
+
+	// Memory may have grown while allocating so the destination has to be read again
+	header := readWasmBytes(m.Memory(), export, out, 8)
+	binary.LittleEndian.PutUint32(header[0:], ptr)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(s)))
+}
+
+func returnWasmString(ctx context.Context, m api.Module, export string, out uint32, s string) {
+	returnWasmSlice(ctx, m, export, out, []byte(s))
+}
+
+// allocWasm calls the module's 'alloc' export, returning a pointer to size bytes.
+// Ownership of the memory is given to the module.
+func allocWasm(ctx context.Context, m api.Module, export string, size uint32) uint32 {
+	alloc := m.ExportedFunction("alloc")
+	if alloc == nil {
+		panic(fmt.Errorf("%s - module must export 'alloc' to receive values from the engine", export))
+	}
+
+	res, err := alloc.Call(ctx, uint64(size))
+	if err != nil {
+		panic(fmt.Errorf("%s - alloc failed: %w", export, err))
+	}
+
+	if len(res) != 1 || api.DecodeU32(res[0]) == 0 {
+		panic(fmt.Errorf("%s - alloc was unable to allocate %d bytes", export, size))
 	}
 
-	return string(buf)
+	return api.DecodeU32(res[0])
 }
 
 func boolToU32(b bool) (r uint32) {
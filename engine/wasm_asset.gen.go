@@ -7,18 +7,103 @@ import (
 )
 
 func (a *Asset) Expose(wasm *WasmRuntime) {
+	wasm.ConvertAndExpose("AssetAtlasFrame", a.AtlasFrame, wasmAtlasFrame)
+	wasm.ConvertAndExpose("AssetAtlasRegion", a.AtlasRegion, wasmAtlasRegion)
+	wasm.ConvertAndExpose("AssetAtlasTexture", a.AtlasTexture, wasmAtlasTexture)
+	wasm.ConvertAndExpose("AssetAtlasTrim", a.AtlasTrim, wasmAtlasTrim)
+	wasm.ConvertAndExpose("AssetLoadAtlas", a.LoadAtlas, wasmLoadAtlas)
 	wasm.ConvertAndExpose("AssetLoadTexture", a.LoadTexture, wasmLoadTexture)
+	wasm.ConvertAndExpose("AssetReadFile", a.ReadFile, wasmReadFile)
+	wasm.ConvertAndExpose("AssetTextureInfo", a.TextureInfo, wasmTextureInfo)
 
 }
 
 // Wasm wrappers for Asset
 
+// Calls Asset.AtlasFrame
+func wasmAtlasFrame(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1_0 := api.DecodeU32(stack[1])
+	arg1_1 := api.DecodeU32(stack[2])
+	r0 := brut.Asset.AtlasFrame(
+		Atlas(arg0),
+		readWasmString(m.Memory(), "AssetAtlasFrame", arg1_0, arg1_1),
+	)
+	stack[0] = api.EncodeU32(uint32(r0))
+}
+
+// Calls Asset.AtlasRegion
+func wasmAtlasRegion(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1_0 := api.DecodeU32(stack[1])
+	arg1_1 := api.DecodeU32(stack[2])
+	out0 := api.DecodeU32(stack[3])
+	r0 := brut.Asset.AtlasRegion(
+		Atlas(arg0),
+		readWasmString(m.Memory(), "AssetAtlasRegion", arg1_0, arg1_1),
+	)
+	returnWasmStruct(m, "AssetAtlasRegion", out0, r0)
+}
+
+// Calls Asset.AtlasTexture
+func wasmAtlasTexture(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	r0 := brut.Asset.AtlasTexture(
+		Atlas(arg0),
+	)
+	stack[0] = api.EncodeU32(uint32(r0))
+}
+
+// Calls Asset.AtlasTrim
+func wasmAtlasTrim(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1_0 := api.DecodeU32(stack[1])
+	arg1_1 := api.DecodeU32(stack[2])
+	out0 := api.DecodeU32(stack[3])
+	r0 := brut.Asset.AtlasTrim(
+		Atlas(arg0),
+		readWasmString(m.Memory(), "AssetAtlasTrim", arg1_0, arg1_1),
+	)
+	returnWasmStruct(m, "AssetAtlasTrim", out0, r0)
+}
+
+// Calls Asset.LoadAtlas
+func wasmLoadAtlas(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	r0 := brut.Asset.LoadAtlas(
+		readWasmString(m.Memory(), "AssetLoadAtlas", arg0_0, arg0_1),
+	)
+	stack[0] = api.EncodeU32(uint32(r0))
+}
+
 // Calls Asset.LoadTexture
 func wasmLoadTexture(ctx context.Context, m api.Module, stack []WasmValue) {
 	arg0_0 := api.DecodeU32(stack[0])
 	arg0_1 := api.DecodeU32(stack[1])
 	r0 := brut.Asset.LoadTexture(
-		readWasmString(m.Memory(), arg0_0, arg0_1),
+		readWasmString(m.Memory(), "AssetLoadTexture", arg0_0, arg0_1),
 	)
 	stack[0] = api.EncodeU32(uint32(r0))
 }
+
+// Calls Asset.ReadFile
+func wasmReadFile(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	out0 := api.DecodeU32(stack[2])
+	r0 := brut.Asset.ReadFile(
+		readWasmString(m.Memory(), "AssetReadFile", arg0_0, arg0_1),
+	)
+	returnWasmSlice(ctx, m, "AssetReadFile", out0, r0)
+}
+
+// Calls Asset.TextureInfo
+func wasmTextureInfo(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	out0 := api.DecodeU32(stack[1])
+	r0 := brut.Asset.TextureInfo(
+		Texture(arg0),
+	)
+	returnWasmStruct(m, "AssetTextureInfo", out0, r0)
+}
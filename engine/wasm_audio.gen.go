@@ -0,0 +1,214 @@
+// Code generated by 'go generate ./...'; DO NOT EDIT.
+package engine
+
+import (
+	"context"
+	"github.com/tetratelabs/wazero/api"
+)
+
+func (a *Audio) Expose(wasm *WasmRuntime) {
+	wasm.ConvertAndExpose("AudioLoadMusic", a.LoadMusic, wasmLoadMusic)
+	wasm.ConvertAndExpose("AudioLoadSound", a.LoadSound, wasmLoadSound)
+	wasm.ConvertAndExpose("AudioPause", a.Pause, wasmPause)
+	wasm.ConvertAndExpose("AudioPlay", a.Play, wasmPlay)
+	wasm.ConvertAndExpose("AudioPlaying", a.Playing, wasmPlaying)
+	wasm.ConvertAndExpose("AudioPushSamples", a.PushSamples, wasmPushSamples)
+	wasm.ConvertAndExpose("AudioQueuedSamples", a.QueuedSamples, wasmQueuedSamples)
+	wasm.ConvertAndExpose("AudioResume", a.Resume, wasmResume)
+	wasm.ConvertAndExpose("AudioSetBusDucking", a.SetBusDucking, wasmSetBusDucking)
+	wasm.ConvertAndExpose("AudioSetBusLowPass", a.SetBusLowPass, wasmSetBusLowPass)
+	wasm.ConvertAndExpose("AudioSetBusMuted", a.SetBusMuted, wasmSetBusMuted)
+	wasm.ConvertAndExpose("AudioSetBusReverb", a.SetBusReverb, wasmSetBusReverb)
+	wasm.ConvertAndExpose("AudioSetBusVolume", a.SetBusVolume, wasmSetBusVolume)
+	wasm.ConvertAndExpose("AudioSetPan", a.SetPan, wasmSetPan)
+	wasm.ConvertAndExpose("AudioSetPitch", a.SetPitch, wasmSetPitch)
+	wasm.ConvertAndExpose("AudioSetVoiceBus", a.SetVoiceBus, wasmSetVoiceBus)
+	wasm.ConvertAndExpose("AudioSetVolume", a.SetVolume, wasmSetVolume)
+	wasm.ConvertAndExpose("AudioStartStream", a.StartStream, wasmStartStream)
+	wasm.ConvertAndExpose("AudioStop", a.Stop, wasmStop)
+
+}
+
+// Wasm wrappers for Audio
+
+// Calls Audio.LoadMusic
+func wasmLoadMusic(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	r0 := brut.Audio.LoadMusic(
+		readWasmString(m.Memory(), "AudioLoadMusic", arg0_0, arg0_1),
+	)
+	stack[0] = api.EncodeU32(uint32(r0))
+}
+
+// Calls Audio.LoadSound
+func wasmLoadSound(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	r0 := brut.Audio.LoadSound(
+		readWasmString(m.Memory(), "AudioLoadSound", arg0_0, arg0_1),
+	)
+	stack[0] = api.EncodeU32(uint32(r0))
+}
+
+// Calls Audio.Pause
+func wasmPause(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	brut.Audio.Pause(
+		Voice(arg0),
+	)
+}
+
+// Calls Audio.Play
+func wasmPlay(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1 := api.DecodeU32(stack[1])
+	r0 := brut.Audio.Play(
+		Sound(arg0),
+		u32ToBool(arg1),
+	)
+	stack[0] = api.EncodeU32(uint32(r0))
+}
+
+// Calls Audio.Playing
+func wasmPlaying(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	r0 := brut.Audio.Playing(
+		Voice(arg0),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
+// Decode buffer for arg0, reused across calls
+var wasmPushSamplesArg0 []float32
+
+// Calls Audio.PushSamples
+func wasmPushSamples(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	arg0 := readWasmSlice(m.Memory(), "AudioPushSamples", arg0_0, arg0_1, &wasmPushSamplesArg0)
+	r0 := brut.Audio.PushSamples(
+		arg0,
+	)
+	stack[0] = api.EncodeI32(int32(r0))
+}
+
+// Calls Audio.QueuedSamples
+func wasmQueuedSamples(ctx context.Context, m api.Module, stack []WasmValue) {
+	r0 := brut.Audio.QueuedSamples()
+	stack[0] = api.EncodeI32(int32(r0))
+}
+
+// Calls Audio.Resume
+func wasmResume(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	brut.Audio.Resume(
+		Voice(arg0),
+	)
+}
+
+// Calls Audio.SetBusDucking
+func wasmSetBusDucking(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1 := api.DecodeU32(stack[1])
+	arg2 := api.DecodeF32(stack[2])
+	brut.Audio.SetBusDucking(
+		Bus(arg0),
+		Bus(arg1),
+		float32(arg2),
+	)
+}
+
+// Calls Audio.SetBusLowPass
+func wasmSetBusLowPass(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1 := api.DecodeF32(stack[1])
+	brut.Audio.SetBusLowPass(
+		Bus(arg0),
+		float32(arg1),
+	)
+}
+
+// Calls Audio.SetBusMuted
+func wasmSetBusMuted(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1 := api.DecodeU32(stack[1])
+	brut.Audio.SetBusMuted(
+		Bus(arg0),
+		u32ToBool(arg1),
+	)
+}
+
+// Calls Audio.SetBusReverb
+func wasmSetBusReverb(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1 := api.DecodeF32(stack[1])
+	brut.Audio.SetBusReverb(
+		Bus(arg0),
+		float32(arg1),
+	)
+}
+
+// Calls Audio.SetBusVolume
+func wasmSetBusVolume(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1 := api.DecodeF32(stack[1])
+	brut.Audio.SetBusVolume(
+		Bus(arg0),
+		float32(arg1),
+	)
+}
+
+// Calls Audio.SetPan
+func wasmSetPan(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1 := api.DecodeF32(stack[1])
+	brut.Audio.SetPan(
+		Voice(arg0),
+		float32(arg1),
+	)
+}
+
+// Calls Audio.SetPitch
+func wasmSetPitch(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1 := api.DecodeF32(stack[1])
+	brut.Audio.SetPitch(
+		Voice(arg0),
+		float32(arg1),
+	)
+}
+
+// Calls Audio.SetVoiceBus
+func wasmSetVoiceBus(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1 := api.DecodeU32(stack[1])
+	brut.Audio.SetVoiceBus(
+		Voice(arg0),
+		Bus(arg1),
+	)
+}
+
+// Calls Audio.SetVolume
+func wasmSetVolume(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1 := api.DecodeF32(stack[1])
+	brut.Audio.SetVolume(
+		Voice(arg0),
+		float32(arg1),
+	)
+}
+
+// Calls Audio.StartStream
+func wasmStartStream(ctx context.Context, m api.Module, stack []WasmValue) {
+	r0 := brut.Audio.StartStream()
+	stack[0] = api.EncodeU32(uint32(r0))
+}
+
+// Calls Audio.Stop
+func wasmStop(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	brut.Audio.Stop(
+		Voice(arg0),
+	)
+}
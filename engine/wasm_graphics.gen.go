@@ -10,10 +10,15 @@ func (a *Graphics) Expose(wasm *WasmRuntime) {
 	wasm.ConvertAndExpose("GraphicsCircle", a.Circle, wasmCircle)
 	wasm.ConvertAndExpose("GraphicsClear", a.Clear, wasmClear)
 	wasm.ConvertAndExpose("GraphicsRectangle", a.Rectangle, wasmRectangle)
+	wasm.ConvertAndExpose("GraphicsScreenshot", a.Screenshot, wasmScreenshot)
 	wasm.ConvertAndExpose("GraphicsSetTargetSize", a.SetTargetSize, wasmSetTargetSize)
+	wasm.ConvertAndExpose("GraphicsSprite", a.Sprite, wasmSprite)
+	wasm.ConvertAndExpose("GraphicsSubmit", a.Submit, wasmSubmit)
 	wasm.ConvertAndExpose("GraphicsText", a.Text, wasmText)
 	wasm.ConvertAndExpose("GraphicsTexture", a.Texture, wasmTexture)
 	wasm.ConvertAndExpose("GraphicsTextureEx", a.TextureEx, wasmTextureEx)
+	wasm.ConvertAndExpose("GraphicsTextureRegion", a.TextureRegion, wasmTextureRegion)
+	wasm.ConvertAndExpose("GraphicsTextureRegionEx", a.TextureRegionEx, wasmTextureRegionEx)
 
 }
 
@@ -70,6 +75,16 @@ func wasmRectangle(ctx context.Context, m api.Module, stack []WasmValue) {
 	)
 }
 
+// Calls Graphics.Screenshot
+func wasmScreenshot(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	r0 := brut.Graphics.Screenshot(
+		readWasmString(m.Memory(), "GraphicsScreenshot", arg0_0, arg0_1),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
 // Calls Graphics.SetTargetSize
 func wasmSetTargetSize(ctx context.Context, m api.Module, stack []WasmValue) {
 	arg0 := api.DecodeI32(stack[0])
@@ -80,6 +95,53 @@ func wasmSetTargetSize(ctx context.Context, m api.Module, stack []WasmValue) {
 	)
 }
 
+// Calls Graphics.Sprite
+func wasmSprite(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1_0 := api.DecodeF32(stack[1])
+	arg1_1 := api.DecodeF32(stack[2])
+	arg1_2 := api.DecodeF32(stack[3])
+	arg1_3 := api.DecodeF32(stack[4])
+	arg2 := api.DecodeF32(stack[5])
+	arg3 := api.DecodeF32(stack[6])
+	arg4 := api.DecodeF32(stack[7])
+	arg5 := api.DecodeF32(stack[8])
+	arg6 := api.DecodeF32(stack[9])
+	arg7 := api.DecodeF32(stack[10])
+	arg8 := api.DecodeF32(stack[11])
+	arg9 := api.DecodeU32(stack[12])
+	arg10_0 := api.DecodeF32(stack[13])
+	arg10_1 := api.DecodeF32(stack[14])
+	arg10_2 := api.DecodeF32(stack[15])
+	arg10_3 := api.DecodeF32(stack[16])
+	brut.Graphics.Sprite(
+		Texture(arg0),
+		Rect{float32(arg1_0), float32(arg1_1), float32(arg1_2), float32(arg1_3)},
+		float32(arg2),
+		float32(arg3),
+		float32(arg4),
+		float32(arg5),
+		float32(arg6),
+		float32(arg7),
+		float32(arg8),
+		Flip(arg9),
+		Color{float32(arg10_0), float32(arg10_1), float32(arg10_2), float32(arg10_3)},
+	)
+}
+
+// Decode buffer for arg0, reused across calls
+var wasmSubmitArg0 []DrawCommand
+
+// Calls Graphics.Submit
+func wasmSubmit(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	arg0 := readWasmSlice(m.Memory(), "GraphicsSubmit", arg0_0, arg0_1, &wasmSubmitArg0)
+	brut.Graphics.Submit(
+		arg0,
+	)
+}
+
 // Calls Graphics.Text
 func wasmText(ctx context.Context, m api.Module, stack []WasmValue) {
 	arg0_0 := api.DecodeU32(stack[0])
@@ -87,7 +149,7 @@ func wasmText(ctx context.Context, m api.Module, stack []WasmValue) {
 	arg1 := api.DecodeF32(stack[2])
 	arg2 := api.DecodeF32(stack[3])
 	brut.Graphics.Text(
-		readWasmString(m.Memory(), arg0_0, arg0_1),
+		readWasmString(m.Memory(), "GraphicsText", arg0_0, arg0_1),
 		float32(arg1),
 		float32(arg2),
 	)
@@ -127,3 +189,48 @@ func wasmTextureEx(ctx context.Context, m api.Module, stack []WasmValue) {
 		Color{float32(arg6_0), float32(arg6_1), float32(arg6_2), float32(arg6_3)},
 	)
 }
+
+// Calls Graphics.TextureRegion
+func wasmTextureRegion(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1_0 := api.DecodeF32(stack[1])
+	arg1_1 := api.DecodeF32(stack[2])
+	arg1_2 := api.DecodeF32(stack[3])
+	arg1_3 := api.DecodeF32(stack[4])
+	arg2 := api.DecodeF32(stack[5])
+	arg3 := api.DecodeF32(stack[6])
+	brut.Graphics.TextureRegion(
+		Texture(arg0),
+		Rect{float32(arg1_0), float32(arg1_1), float32(arg1_2), float32(arg1_3)},
+		float32(arg2),
+		float32(arg3),
+	)
+}
+
+// Calls Graphics.TextureRegionEx
+func wasmTextureRegionEx(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	arg1_0 := api.DecodeF32(stack[1])
+	arg1_1 := api.DecodeF32(stack[2])
+	arg1_2 := api.DecodeF32(stack[3])
+	arg1_3 := api.DecodeF32(stack[4])
+	arg2 := api.DecodeF32(stack[5])
+	arg3 := api.DecodeF32(stack[6])
+	arg4 := api.DecodeF32(stack[7])
+	arg5 := api.DecodeF32(stack[8])
+	arg6 := api.DecodeF32(stack[9])
+	arg7_0 := api.DecodeF32(stack[10])
+	arg7_1 := api.DecodeF32(stack[11])
+	arg7_2 := api.DecodeF32(stack[12])
+	arg7_3 := api.DecodeF32(stack[13])
+	brut.Graphics.TextureRegionEx(
+		Texture(arg0),
+		Rect{float32(arg1_0), float32(arg1_1), float32(arg1_2), float32(arg1_3)},
+		float32(arg2),
+		float32(arg3),
+		float32(arg4),
+		float32(arg5),
+		float32(arg6),
+		Color{float32(arg7_0), float32(arg7_1), float32(arg7_2), float32(arg7_3)},
+	)
+}
@@ -7,16 +7,147 @@ import (
 )
 
 func (a *Input) Expose(wasm *WasmRuntime) {
+	wasm.ConvertAndExpose("InputActionHeld", a.ActionHeld, wasmActionHeld)
+	wasm.ConvertAndExpose("InputActionJustPressed", a.ActionJustPressed, wasmActionJustPressed)
+	wasm.ConvertAndExpose("InputActionJustReleased", a.ActionJustReleased, wasmActionJustReleased)
+	wasm.ConvertAndExpose("InputAxisValue", a.AxisValue, wasmAxisValue)
+	wasm.ConvertAndExpose("InputBindAction", a.BindAction, wasmBindAction)
+	wasm.ConvertAndExpose("InputBindAxis", a.BindAxis, wasmBindAxis)
+	wasm.ConvertAndExpose("InputBindGamepadAxis", a.BindGamepadAxis, wasmBindGamepadAxis)
+	wasm.ConvertAndExpose("InputClearBindings", a.ClearBindings, wasmClearBindings)
+	wasm.ConvertAndExpose("InputCursorDeltaX", a.CursorDeltaX, wasmCursorDeltaX)
+	wasm.ConvertAndExpose("InputCursorDeltaY", a.CursorDeltaY, wasmCursorDeltaY)
 	wasm.ConvertAndExpose("InputCursorX", a.CursorX, wasmCursorX)
 	wasm.ConvertAndExpose("InputCursorY", a.CursorY, wasmCursorY)
-	wasm.ConvertAndExpose("InputDown", a.Down, wasmDown)
-	wasm.ConvertAndExpose("InputPressed", a.Pressed, wasmPressed)
-	wasm.ConvertAndExpose("InputUp", a.Up, wasmUp)
+	wasm.ConvertAndExpose("InputGamepadAxis", a.GamepadAxis, wasmGamepadAxis)
+	wasm.ConvertAndExpose("InputGamepadConnected", a.GamepadConnected, wasmGamepadConnected)
+	wasm.ConvertAndExpose("InputGamepadHeld", a.GamepadHeld, wasmGamepadHeld)
+	wasm.ConvertAndExpose("InputGamepadHeldDuration", a.GamepadHeldDuration, wasmGamepadHeldDuration)
+	wasm.ConvertAndExpose("InputGamepadJustPressed", a.GamepadJustPressed, wasmGamepadJustPressed)
+	wasm.ConvertAndExpose("InputGamepadJustReleased", a.GamepadJustReleased, wasmGamepadJustReleased)
+	wasm.ConvertAndExpose("InputGetCursorMode", a.GetCursorMode, wasmGetCursorMode)
+	wasm.ConvertAndExpose("InputHeld", a.Held, wasmHeld)
+	wasm.ConvertAndExpose("InputHeldDuration", a.HeldDuration, wasmHeldDuration)
+	wasm.ConvertAndExpose("InputJustPressed", a.JustPressed, wasmJustPressed)
+	wasm.ConvertAndExpose("InputJustReleased", a.JustReleased, wasmJustReleased)
+	wasm.ConvertAndExpose("InputLoadBindings", a.LoadBindings, wasmLoadBindings)
+	wasm.ConvertAndExpose("InputModifierDown", a.ModifierDown, wasmModifierDown)
+	wasm.ConvertAndExpose("InputRepeated", a.Repeated, wasmRepeated)
+	wasm.ConvertAndExpose("InputSaveBindings", a.SaveBindings, wasmSaveBindings)
+	wasm.ConvertAndExpose("InputSetCursorMode", a.SetCursorMode, wasmSetCursorMode)
+	wasm.ConvertAndExpose("InputSetGamepadDeadzone", a.SetGamepadDeadzone, wasmSetGamepadDeadzone)
+	wasm.ConvertAndExpose("InputSetTouchAsMouse", a.SetTouchAsMouse, wasmSetTouchAsMouse)
+	wasm.ConvertAndExpose("InputTextInput", a.TextInput, wasmTextInput)
+	wasm.ConvertAndExpose("InputTouchCount", a.TouchCount, wasmTouchCount)
+	wasm.ConvertAndExpose("InputTouchDuration", a.TouchDuration, wasmTouchDuration)
+	wasm.ConvertAndExpose("InputTouchID", a.TouchID, wasmTouchID)
+	wasm.ConvertAndExpose("InputTouchJustBegan", a.TouchJustBegan, wasmTouchJustBegan)
+	wasm.ConvertAndExpose("InputTouchJustEnded", a.TouchJustEnded, wasmTouchJustEnded)
+	wasm.ConvertAndExpose("InputTouchX", a.TouchX, wasmTouchX)
+	wasm.ConvertAndExpose("InputTouchY", a.TouchY, wasmTouchY)
+	wasm.ConvertAndExpose("InputWheelX", a.WheelX, wasmWheelX)
+	wasm.ConvertAndExpose("InputWheelY", a.WheelY, wasmWheelY)
 
 }
 
 // Wasm wrappers for Input
 
+// Calls Input.ActionHeld
+func wasmActionHeld(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	r0 := brut.Input.ActionHeld(
+		readWasmString(m.Memory(), "InputActionHeld", arg0_0, arg0_1),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
+// Calls Input.ActionJustPressed
+func wasmActionJustPressed(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	r0 := brut.Input.ActionJustPressed(
+		readWasmString(m.Memory(), "InputActionJustPressed", arg0_0, arg0_1),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
+// Calls Input.ActionJustReleased
+func wasmActionJustReleased(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	r0 := brut.Input.ActionJustReleased(
+		readWasmString(m.Memory(), "InputActionJustReleased", arg0_0, arg0_1),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
+// Calls Input.AxisValue
+func wasmAxisValue(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	r0 := brut.Input.AxisValue(
+		readWasmString(m.Memory(), "InputAxisValue", arg0_0, arg0_1),
+	)
+	stack[0] = api.EncodeF32(float32(r0))
+}
+
+// Calls Input.BindAction
+func wasmBindAction(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	arg1 := api.DecodeU32(stack[2])
+	brut.Input.BindAction(
+		readWasmString(m.Memory(), "InputBindAction", arg0_0, arg0_1),
+		InputEvent(arg1),
+	)
+}
+
+// Calls Input.BindAxis
+func wasmBindAxis(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	arg1 := api.DecodeU32(stack[2])
+	arg2 := api.DecodeU32(stack[3])
+	brut.Input.BindAxis(
+		readWasmString(m.Memory(), "InputBindAxis", arg0_0, arg0_1),
+		InputEvent(arg1),
+		InputEvent(arg2),
+	)
+}
+
+// Calls Input.BindGamepadAxis
+func wasmBindGamepadAxis(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	arg1 := api.DecodeU32(stack[2])
+	brut.Input.BindGamepadAxis(
+		readWasmString(m.Memory(), "InputBindGamepadAxis", arg0_0, arg0_1),
+		GamepadAxis(arg1),
+	)
+}
+
+// Calls Input.ClearBindings
+func wasmClearBindings(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	brut.Input.ClearBindings(
+		readWasmString(m.Memory(), "InputClearBindings", arg0_0, arg0_1),
+	)
+}
+
+// Calls Input.CursorDeltaX
+func wasmCursorDeltaX(ctx context.Context, m api.Module, stack []WasmValue) {
+	r0 := brut.Input.CursorDeltaX()
+	stack[0] = api.EncodeF32(float32(r0))
+}
+
+// Calls Input.CursorDeltaY
+func wasmCursorDeltaY(ctx context.Context, m api.Module, stack []WasmValue) {
+	r0 := brut.Input.CursorDeltaY()
+	stack[0] = api.EncodeF32(float32(r0))
+}
+
 // Calls Input.CursorX
 func wasmCursorX(ctx context.Context, m api.Module, stack []WasmValue) {
 	r0 := brut.Input.CursorX()
@@ -29,29 +160,249 @@ func wasmCursorY(ctx context.Context, m api.Module, stack []WasmValue) {
 	stack[0] = api.EncodeF32(float32(r0))
 }
 
-// Calls Input.Down
-func wasmDown(ctx context.Context, m api.Module, stack []WasmValue) {
+// Calls Input.GamepadAxis
+func wasmGamepadAxis(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeI32(stack[0])
+	arg1 := api.DecodeU32(stack[1])
+	r0 := brut.Input.GamepadAxis(
+		int32(arg0),
+		GamepadAxis(arg1),
+	)
+	stack[0] = api.EncodeF32(float32(r0))
+}
+
+// Calls Input.GamepadConnected
+func wasmGamepadConnected(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeI32(stack[0])
+	r0 := brut.Input.GamepadConnected(
+		int32(arg0),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
+// Calls Input.GamepadHeld
+func wasmGamepadHeld(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeI32(stack[0])
+	arg1 := api.DecodeU32(stack[1])
+	r0 := brut.Input.GamepadHeld(
+		int32(arg0),
+		InputEvent(arg1),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
+// Calls Input.GamepadHeldDuration
+func wasmGamepadHeldDuration(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeI32(stack[0])
+	arg1 := api.DecodeU32(stack[1])
+	r0 := brut.Input.GamepadHeldDuration(
+		int32(arg0),
+		InputEvent(arg1),
+	)
+	stack[0] = api.EncodeI32(int32(r0))
+}
+
+// Calls Input.GamepadJustPressed
+func wasmGamepadJustPressed(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeI32(stack[0])
+	arg1 := api.DecodeU32(stack[1])
+	r0 := brut.Input.GamepadJustPressed(
+		int32(arg0),
+		InputEvent(arg1),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
+// Calls Input.GamepadJustReleased
+func wasmGamepadJustReleased(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeI32(stack[0])
+	arg1 := api.DecodeU32(stack[1])
+	r0 := brut.Input.GamepadJustReleased(
+		int32(arg0),
+		InputEvent(arg1),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
+// Calls Input.GetCursorMode
+func wasmGetCursorMode(ctx context.Context, m api.Module, stack []WasmValue) {
+	r0 := brut.Input.GetCursorMode()
+	stack[0] = api.EncodeU32(uint32(r0))
+}
+
+// Calls Input.Held
+func wasmHeld(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	r0 := brut.Input.Held(
+		InputEvent(arg0),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
+// Calls Input.HeldDuration
+func wasmHeldDuration(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	r0 := brut.Input.HeldDuration(
+		InputEvent(arg0),
+	)
+	stack[0] = api.EncodeI32(int32(r0))
+}
+
+// Calls Input.JustPressed
+func wasmJustPressed(ctx context.Context, m api.Module, stack []WasmValue) {
 	arg0 := api.DecodeU32(stack[0])
-	r0 := brut.Input.Down(
+	r0 := brut.Input.JustPressed(
 		InputEvent(arg0),
 	)
 	stack[0] = api.EncodeU32(boolToU32(r0))
 }
 
-// Calls Input.Pressed
-func wasmPressed(ctx context.Context, m api.Module, stack []WasmValue) {
+// Calls Input.JustReleased
+func wasmJustReleased(ctx context.Context, m api.Module, stack []WasmValue) {
 	arg0 := api.DecodeU32(stack[0])
-	r0 := brut.Input.Pressed(
+	r0 := brut.Input.JustReleased(
 		InputEvent(arg0),
 	)
 	stack[0] = api.EncodeU32(boolToU32(r0))
 }
 
-// Calls Input.Up
-func wasmUp(ctx context.Context, m api.Module, stack []WasmValue) {
+// Calls Input.LoadBindings
+func wasmLoadBindings(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	r0 := brut.Input.LoadBindings(
+		readWasmString(m.Memory(), "InputLoadBindings", arg0_0, arg0_1),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
+// Calls Input.ModifierDown
+func wasmModifierDown(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	r0 := brut.Input.ModifierDown(
+		Modifier(arg0),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
+// Calls Input.Repeated
+func wasmRepeated(ctx context.Context, m api.Module, stack []WasmValue) {
 	arg0 := api.DecodeU32(stack[0])
-	r0 := brut.Input.Up(
+	r0 := brut.Input.Repeated(
 		InputEvent(arg0),
 	)
 	stack[0] = api.EncodeU32(boolToU32(r0))
 }
+
+// Calls Input.SaveBindings
+func wasmSaveBindings(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0_0 := api.DecodeU32(stack[0])
+	arg0_1 := api.DecodeU32(stack[1])
+	r0 := brut.Input.SaveBindings(
+		readWasmString(m.Memory(), "InputSaveBindings", arg0_0, arg0_1),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
+// Calls Input.SetCursorMode
+func wasmSetCursorMode(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	brut.Input.SetCursorMode(
+		CursorMode(arg0),
+	)
+}
+
+// Calls Input.SetGamepadDeadzone
+func wasmSetGamepadDeadzone(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeF32(stack[0])
+	brut.Input.SetGamepadDeadzone(
+		float32(arg0),
+	)
+}
+
+// Calls Input.SetTouchAsMouse
+func wasmSetTouchAsMouse(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeU32(stack[0])
+	brut.Input.SetTouchAsMouse(
+		u32ToBool(arg0),
+	)
+}
+
+// Calls Input.TextInput
+func wasmTextInput(ctx context.Context, m api.Module, stack []WasmValue) {
+	out0 := api.DecodeU32(stack[0])
+	r0 := brut.Input.TextInput()
+	returnWasmString(ctx, m, "InputTextInput", out0, r0)
+}
+
+// Calls Input.TouchCount
+func wasmTouchCount(ctx context.Context, m api.Module, stack []WasmValue) {
+	r0 := brut.Input.TouchCount()
+	stack[0] = api.EncodeI32(int32(r0))
+}
+
+// Calls Input.TouchDuration
+func wasmTouchDuration(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeI32(stack[0])
+	r0 := brut.Input.TouchDuration(
+		int32(arg0),
+	)
+	stack[0] = api.EncodeI32(int32(r0))
+}
+
+// Calls Input.TouchID
+func wasmTouchID(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeI32(stack[0])
+	r0 := brut.Input.TouchID(
+		int32(arg0),
+	)
+	stack[0] = api.EncodeI32(int32(r0))
+}
+
+// Calls Input.TouchJustBegan
+func wasmTouchJustBegan(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeI32(stack[0])
+	r0 := brut.Input.TouchJustBegan(
+		int32(arg0),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
+// Calls Input.TouchJustEnded
+func wasmTouchJustEnded(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeI32(stack[0])
+	r0 := brut.Input.TouchJustEnded(
+		int32(arg0),
+	)
+	stack[0] = api.EncodeU32(boolToU32(r0))
+}
+
+// Calls Input.TouchX
+func wasmTouchX(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeI32(stack[0])
+	r0 := brut.Input.TouchX(
+		int32(arg0),
+	)
+	stack[0] = api.EncodeF32(float32(r0))
+}
+
+// Calls Input.TouchY
+func wasmTouchY(ctx context.Context, m api.Module, stack []WasmValue) {
+	arg0 := api.DecodeI32(stack[0])
+	r0 := brut.Input.TouchY(
+		int32(arg0),
+	)
+	stack[0] = api.EncodeF32(float32(r0))
+}
+
+// Calls Input.WheelX
+func wasmWheelX(ctx context.Context, m api.Module, stack []WasmValue) {
+	r0 := brut.Input.WheelX()
+	stack[0] = api.EncodeF32(float32(r0))
+}
+
+// Calls Input.WheelY
+func wasmWheelY(ctx context.Context, m api.Module, stack []WasmValue) {
+	r0 := brut.Input.WheelY()
+	stack[0] = api.EncodeF32(float32(r0))
+}
@@ -10,6 +10,8 @@ func (a *Platform) Expose(wasm *WasmRuntime) {
 	wasm.ConvertAndExpose("PlatformExit", a.Exit, wasmExit)
 	wasm.ConvertAndExpose("PlatformFps", a.Fps, wasmFps)
 	wasm.ConvertAndExpose("PlatformLog", a.Log, wasmLog)
+	wasm.ConvertAndExpose("PlatformRandom", a.Random, wasmRandom)
+	wasm.ConvertAndExpose("PlatformReloadCount", a.ReloadCount, wasmReloadCount)
 	wasm.ConvertAndExpose("PlatformSetScreenSize", a.SetScreenSize, wasmSetScreenSize)
 	wasm.ConvertAndExpose("PlatformSetTitle", a.SetTitle, wasmSetTitle)
 	wasm.ConvertAndExpose("PlatformTps", a.Tps, wasmTps)
@@ -34,10 +36,22 @@ func wasmLog(ctx context.Context, m api.Module, stack []WasmValue) {
 	arg0_0 := api.DecodeU32(stack[0])
 	arg0_1 := api.DecodeU32(stack[1])
 	brut.Platform.Log(
-		readWasmString(m.Memory(), arg0_0, arg0_1),
+		readWasmString(m.Memory(), "PlatformLog", arg0_0, arg0_1),
 	)
 }
 
+// Calls Platform.Random
+func wasmRandom(ctx context.Context, m api.Module, stack []WasmValue) {
+	r0 := brut.Platform.Random()
+	stack[0] = api.EncodeF32(float32(r0))
+}
+
+// Calls Platform.ReloadCount
+func wasmReloadCount(ctx context.Context, m api.Module, stack []WasmValue) {
+	r0 := brut.Platform.ReloadCount()
+	stack[0] = api.EncodeI32(int32(r0))
+}
+
 // Calls Platform.SetScreenSize
 func wasmSetScreenSize(ctx context.Context, m api.Module, stack []WasmValue) {
 	arg0 := api.DecodeI32(stack[0])
@@ -53,7 +67,7 @@ func wasmSetTitle(ctx context.Context, m api.Module, stack []WasmValue) {
 	arg0_0 := api.DecodeU32(stack[0])
 	arg0_1 := api.DecodeU32(stack[1])
 	brut.Platform.SetTitle(
-		readWasmString(m.Memory(), arg0_0, arg0_1),
+		readWasmString(m.Memory(), "PlatformSetTitle", arg0_0, arg0_1),
 	)
 }
 
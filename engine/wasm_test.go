@@ -0,0 +1,266 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// tickingModule counts its ticks in mem[0] and stores version in mem[4] every tick.
+// If edit isn't nil it's given the module to add to before it's assembled.
+func tickingModule(version int32, edit func(m *testModule)) []byte {
+	m := newTestModule()
+	m.export("update", nil,
+		i32Const(0), i32Const(0), i32Load(0), i32Const(1), []byte{opI32Add}, i32Store(0),
+		i32Const(0), i32Const(version), i32Store(4),
+	)
+
+	if edit != nil {
+		edit(m)
+	}
+
+	return m.bytes()
+}
+
+// tick runs the game loop once, applying any reload that was queued
+func tick(t *testing.T) {
+	t.Helper()
+
+	err := brut.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readU32 reads a u32 from the running module's memory
+func readU32(t *testing.T, offset uint32) uint32 {
+	t.Helper()
+
+	v, ok := brut.wasm.mod.Memory().ReadUint32Le(offset)
+	if !ok {
+		t.Fatalf("%d is outside of memory", offset)
+	}
+
+	return v
+}
+
+// expectRollback reloads src, which is expected to fail with an error containing msg,
+// and checks that version 1 of tickingModule keeps running with its state intact.
+func expectRollback(t *testing.T, src []byte, msg string) {
+	t.Helper()
+
+	tick(t)
+	tick(t)
+
+	brut.queueReload(src)
+	tick(t)
+	tick(t)
+
+	if !strings.Contains(brut.reloadError, "still running the previous module") || !strings.Contains(brut.reloadError, msg) {
+		t.Fatalf("expected the overlay to show a reload error containing %q, got %q", msg, brut.reloadError)
+	}
+
+	if reloads := brut.Platform.ReloadCount(); reloads != 0 {
+		t.Errorf("expected no reloads to be counted, got %d", reloads)
+	}
+
+	if version := readU32(t, 4); version != 1 {
+		t.Errorf("expected the previous module to still be running, got version %d", version)
+	}
+
+	if ticks := readU32(t, 0); ticks != 4 {
+		t.Errorf("expected the previous module to have ticked 4 times, got %d", ticks)
+	}
+}
+
+func TestReloadRollback(t *testing.T) {
+	i32 := api.ValueTypeI32
+
+	tests := []struct {
+		name     string
+		old, new func(m *testModule)
+		err      string
+	}{
+		{
+			name: "missing memory",
+			new:  func(m *testModule) { m.hideMemory = true },
+			err:  "doesn't export its memory",
+		},
+		{
+			name: "missing callback",
+			old:  func(m *testModule) { m.export("render", nil) },
+			err:  `missing "render"`,
+		},
+		{
+			name: "on_reload rejected",
+			new:  func(m *testModule) { m.export("on_reload", valueTypes(i32, 1), i32Const(0)) },
+			err:  "on_reload rejected",
+		},
+		{
+			name: "memory transfer",
+			old:  func(m *testModule) { m.pages = 2 },
+			new:  func(m *testModule) { m.maxPages = 1 },
+			err:  "unable to resize",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			startModule(t, tickingModule(1, test.old), Config{})
+			expectRollback(t, tickingModule(2, test.new), test.err)
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	startModule(t, tickingModule(1, nil), Config{})
+
+	tick(t)
+	tick(t)
+
+	brut.queueReload(tickingModule(2, nil))
+	tick(t)
+
+	if brut.reloadError != "" {
+		t.Fatalf("reload failed: %s", brut.reloadError)
+	}
+
+	if reloads := brut.Platform.ReloadCount(); reloads != 1 {
+		t.Errorf("expected 1 reload, got %d", reloads)
+	}
+
+	if version := readU32(t, 4); version != 2 {
+		t.Errorf("expected the new module to be running, got version %d", version)
+	}
+
+	if ticks := readU32(t, 0); ticks != 3 {
+		t.Errorf("expected memory to be kept across the reload, the module counted %d of 3 ticks", ticks)
+	}
+}
+
+// stateProtocol adds the state protocol to a tickingModule.
+// Its setup writes a marker to mem[12], which is only carried over when memory is copied as is.
+type stateProtocol struct {
+	serialize   bool
+	header      int32 // address returned by serialize_state, the header there describes mem[0:4] as version 3
+	deserialize bool
+	accept      int32 // returned by deserialize_state, which stores the version in mem[8] and the state in mem[0]
+}
+
+func (p stateProtocol) edit(m *testModule) {
+	i32 := api.ValueTypeI32
+
+	m.export("setup", nil, i32Const(0), i32Const(0xab), i32Store(12))
+
+	if p.serialize {
+		m.export("serialize_state", valueTypes(i32, 1),
+			i32Const(0), i32Const(3), i32Store(64),
+			i32Const(0), i32Const(0), i32Store(68),
+			i32Const(0), i32Const(4), i32Store(72),
+			i32Const(p.header),
+		)
+	}
+
+	if p.deserialize {
+		m.exportFunc("alloc", valueTypes(i32, 1), valueTypes(i32, 1), i32Const(256))
+		m.exportFunc("deserialize_state", valueTypes(i32, 3), valueTypes(i32, 1),
+			i32Const(0), localGet(0), i32Store(8),
+			i32Const(0), localGet(1), i32Load(0), i32Store(0),
+			i32Const(p.accept),
+		)
+	}
+}
+
+func TestMigrateState(t *testing.T) {
+	protocol := stateProtocol{serialize: true, header: 64, deserialize: true, accept: 1}
+	startModule(t, tickingModule(1, protocol.edit), Config{})
+
+	tick(t)
+	tick(t)
+
+	brut.queueReload(tickingModule(2, protocol.edit))
+	tick(t)
+
+	if brut.reloadError != "" {
+		t.Fatalf("reload failed: %s", brut.reloadError)
+	}
+
+	if version := readU32(t, 8); version != 3 {
+		t.Errorf("expected deserialize_state to be given version 3, got %d", version)
+	}
+
+	if ticks := readU32(t, 0); ticks != 3 {
+		t.Errorf("expected the state to be migrated, the module counted %d of 3 ticks", ticks)
+	}
+
+	if marker := readU32(t, 12); marker != 0 {
+		t.Errorf("expected only the serialized state to be migrated, memory was copied as is")
+	}
+}
+
+func TestMigrateStateRollback(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new stateProtocol
+		err      string
+	}{
+		{
+			name: "version rejected",
+			old:  stateProtocol{serialize: true, header: 64},
+			new:  stateProtocol{deserialize: true, accept: 0},
+			err:  "version 3 was rejected",
+		},
+		{
+			name: "header outside memory",
+			old:  stateProtocol{serialize: true, header: -16},
+			new:  stateProtocol{deserialize: true, accept: 1},
+			err:  "state header is outside of memory",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			startModule(t, tickingModule(1, test.old.edit), Config{})
+			expectRollback(t, tickingModule(2, test.new.edit), test.err)
+		})
+	}
+}
+
+func TestMigrateStateFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new stateProtocol
+	}{
+		{name: "serialize only", old: stateProtocol{serialize: true, header: 64}},
+		{name: "deserialize only", new: stateProtocol{deserialize: true, accept: 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			startModule(t, tickingModule(1, test.old.edit), Config{})
+
+			tick(t)
+			tick(t)
+
+			brut.queueReload(tickingModule(2, test.new.edit))
+			tick(t)
+
+			if brut.reloadError != "" {
+				t.Fatalf("reload failed: %s", brut.reloadError)
+			}
+
+			if marker := readU32(t, 12); marker != 0xab {
+				t.Errorf("expected memory to be copied as is, the marker is %#x", marker)
+			}
+
+			if version := readU32(t, 8); version != 0 {
+				t.Errorf("expected deserialize_state not to be called, it was given version %d", version)
+			}
+
+			if ticks := readU32(t, 0); ticks != 3 {
+				t.Errorf("expected memory to be kept across the reload, the module counted %d of 3 ticks", ticks)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+/*
+
+The directory holding the module is watched rather than the module itself, as
+compilers often write to a temporary file and rename it into place, which
+replaces the file being watched.
+
+Events are debounced so a module written in chunks is only reloaded once it's
+finished, and a checksum of the last module loaded (or attempted) skips reloads
+when a rebuild produced the same module.
+
+The watcher only queues the new module; it's swapped in by the game loop at the
+start of the next tick, before assets, input, or the module's update run. A
+reload never happens during a callback, and if several modules are queued
+between ticks only the newest is loaded. When EngineSetupAfterReload is set,
+setup is called on the new module in the same tick, before update.
+
+*/
+
+// reloadDebounce is how long the module must go without changes before it's reloaded
+const reloadDebounce = 200 * time.Millisecond
+
+// watchModule starts watching the module for changes. Failing to watch isn't fatal, hot reloading is disabled instead.
+func (b *BrutEngine) watchModule() {
+	module, err := filepath.Abs(b.Config.Module)
+	if err != nil {
+		LogWarn("engine - unable to watch %s: %s", b.Config.Module, err)
+		return
+	}
+
+	src, err := os.ReadFile(module)
+	if err != nil {
+		LogWarn("engine - unable to watch %s: %s", module, err)
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		LogWarn("engine - unable to setup watcher: %s", err)
+		return
+	}
+
+	err = watcher.Add(filepath.Dir(module))
+	if err != nil {
+		_ = watcher.Close()
+		LogWarn("engine - unable to watch %s: %s", filepath.Dir(module), err)
+		return
+	}
+
+	b.moduleWatcher = watcher
+	go b.watchForChanges(watcher, module, sha256.Sum256(src))
+}
+
+// unwatchModule stops watching the module, its goroutine exits once the watcher is closed
+func (b *BrutEngine) unwatchModule() {
+	if b.moduleWatcher != nil {
+		_ = b.moduleWatcher.Close()
+		b.moduleWatcher = nil
+	}
+}
+
+func (b *BrutEngine) watchForChanges(watcher *fsnotify.Watcher, module string, checksum [sha256.Size]byte) {
+	LogDebug("engine - watching %s for changes", module)
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			// Renaming a file into place is reported as a Create
+			if event.Name != module || !(event.Has(fsnotify.Write) || event.Has(fsnotify.Create)) {
+				continue
+			}
+
+			// Drain a pending fire so it doesn't reload before the module's finished being written
+			if !debounce.Stop() {
+				select {
+				case <-debounce.C:
+				default:
+				}
+			}
+
+			debounce.Reset(reloadDebounce)
+
+		case <-debounce.C:
+			src, err := os.ReadFile(module)
+			if err != nil {
+				LogWarn("engine - unable to read %q: %s", module, err)
+				continue
+			}
+
+			sum := sha256.Sum256(src)
+			if sum == checksum {
+				LogDebug("engine - %q is unchanged, skipping reload", module)
+				continue
+			}
+
+			// Failed modules aren't retried until they change
+			checksum = sum
+			b.queueReload(src)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+
+			LogError("engine - %s", err)
+		}
+	}
+}
+
+// queueReload schedules src to be loaded at the start of the next tick, replacing any module already queued
+func (b *BrutEngine) queueReload(src []byte) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.pendingReload = src
+}
+
+// applyPendingReload reloads the module queued by the watcher, if there is one. It's called by the game loop.
+func (b *BrutEngine) applyPendingReload() {
+	b.mut.Lock()
+	src := b.pendingReload
+	b.pendingReload = nil
+	b.mut.Unlock()
+
+	if src == nil {
+		return
+	}
+
+	LogDebug("engine - reloading %q", b.Config.Module)
+
+	needsSetup := b.Config.Engine&EngineSetupAfterReload != 0
+	err := b.wasm.Reload(src, !needsSetup)
+	if err != nil {
+		LogError("engine - reload failed, still running the previous module: %s", err)
+		b.reloadError = fmt.Sprintf("Reload failed, still running the previous module:\n%s", err)
+		return
+	}
+
+	b.reloadError = ""
+	b.needsToCallSetup = needsSetup
+	b.Platform.reloads += 1
+}
@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestQueueReloadWhileTicking queues reloads from another goroutine like the watcher does, while the game loop ticks
+func TestQueueReloadWhileTicking(t *testing.T) {
+	const versions = 40
+
+	startModule(t, tickingModule(1, nil), Config{})
+
+	modules := make([][]byte, versions+1)
+	for v := 2; v <= versions; v += 1 {
+		modules[v] = tickingModule(int32(v), nil)
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for v := 2; v <= versions; v += 1 {
+			brut.queueReload(modules[v])
+			time.Sleep(500 * time.Microsecond)
+		}
+	}()
+
+	var (
+		ticks   uint32
+		changes int32
+		last    uint32 = 1
+	)
+
+	tick := func() {
+		err := brut.Update()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ticks += 1
+
+		mem := brut.wasm.mod.Memory()
+		version, _ := mem.ReadUint32Le(4)
+
+		// Every module queued is newer than the last, so a reload always changes the version
+		if version < last {
+			t.Fatalf("tick %d: version went from %d back to %d", ticks, last, version)
+		}
+
+		if version != last {
+			changes += 1
+			last = version
+		}
+
+		count, _ := mem.ReadUint32Le(0)
+		if count != ticks {
+			t.Fatalf("tick %d: module counted %d ticks, memory wasn't kept across a reload", ticks, count)
+		}
+	}
+
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+
+		tick()
+	}
+
+	// The last module queued is applied on the tick after the goroutine is done
+	tick()
+
+	if brut.reloadError != "" {
+		t.Fatalf("a reload failed: %s", brut.reloadError)
+	}
+
+	if last != versions {
+		t.Errorf("expected to end on version %d, got %d", versions, last)
+	}
+
+	if reloads := brut.Platform.ReloadCount(); reloads != changes {
+		t.Errorf("expected %d reloads, one per version change, got %d", changes, reloads)
+	}
+
+	t.Logf("%d reloads over %d ticks", changes, ticks)
+
+	if changes < 2 {
+		t.Errorf("expected reloads to be spread over several ticks, got %d", changes)
+	}
+}
+
+func TestUnwatchModule(t *testing.T) {
+	startModule(t, tickingModule(1, nil), Config{})
+
+	brut.watchModule()
+	if brut.moduleWatcher == nil {
+		t.Fatal("expected the module to be watched")
+	}
+
+	// takePending waits up to wait for a module to be queued, returning it
+	takePending := func(wait time.Duration) []byte {
+		for deadline := time.Now().Add(wait); ; time.Sleep(10 * time.Millisecond) {
+			brut.mut.Lock()
+			src := brut.pendingReload
+			brut.pendingReload = nil
+			brut.mut.Unlock()
+
+			if src != nil || time.Now().After(deadline) {
+				return src
+			}
+		}
+	}
+
+	writeModule := func(version int32) {
+		err := os.WriteFile(brut.Config.Module, tickingModule(version, nil), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeModule(2)
+	if takePending(10*reloadDebounce) == nil {
+		t.Fatal("expected a change to be queued while the module is watched")
+	}
+
+	brut.unwatchModule()
+	if brut.moduleWatcher != nil {
+		t.Fatal("expected the watcher to be closed")
+	}
+
+	writeModule(3)
+	if takePending(2*reloadDebounce) != nil {
+		t.Error("expected changes to be ignored once the module isn't watched")
+	}
+}
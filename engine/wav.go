@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"os"
+)
+
+// wavWriter writes 16-bit stereo samples to a wav file. The header's sizes are filled in by Close.
+type wavWriter struct {
+	file *os.File
+	out  *bufio.Writer
+	size uint32 // bytes of samples written
+}
+
+type wavHeader struct {
+	Riff          [4]byte
+	RiffSize      uint32
+	Wave          [4]byte
+	Fmt           [4]byte
+	FmtSize       uint32
+	Format        uint16
+	Channels      uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+	Data          [4]byte
+	DataSize      uint32
+}
+
+const (
+	wavChannels       = 2
+	wavBitsPerSample  = 16
+	wavRiffSizeOffset = 4
+	wavDataSizeOffset = 40
+)
+
+func newWavWriter(path string, sampleRate int) (*wavWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &wavWriter{file: file, out: bufio.NewWriter(file)}
+
+	header := wavHeader{
+		FmtSize:       16,
+		Format:        1, // pcm
+		Channels:      wavChannels,
+		SampleRate:    uint32(sampleRate),
+		ByteRate:      uint32(sampleRate * wavChannels * wavBitsPerSample / 8),
+		BlockAlign:    wavChannels * wavBitsPerSample / 8,
+		BitsPerSample: wavBitsPerSample,
+	}
+
+	copy(header.Riff[:], "RIFF")
+	copy(header.Wave[:], "WAVE")
+	copy(header.Fmt[:], "fmt ")
+	copy(header.Data[:], "data")
+
+	err = binary.Write(w.out, binary.LittleEndian, &header)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *wavWriter) Write(samples []byte) (int, error) {
+	n, err := w.out.Write(samples)
+	w.size += uint32(n)
+	return n, err
+}
+
+func (w *wavWriter) Close() error {
+	err := w.out.Flush()
+	if err == nil {
+		// The riff chunk holds everything after its size
+		err = errors.Join(
+			w.writeSize(wavRiffSizeOffset, w.size+wavDataSizeOffset-wavRiffSizeOffset),
+			w.writeSize(wavDataSizeOffset, w.size),
+		)
+	}
+
+	return errors.Join(err, w.file.Close())
+}
+
+func (w *wavWriter) writeSize(offset int64, size uint32) error {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], size)
+
+	_, err := w.file.WriteAt(b[:], offset)
+	return err
+}
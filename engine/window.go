@@ -0,0 +1,80 @@
+//go:build !headless
+
+package engine
+
+import (
+	"errors"
+
+	eb "github.com/hajimehoshi/ebiten/v2"
+)
+
+/*
+
+Everything that needs a window goes through ebiten, which connects to a display
+as soon as it's imported. Builds made with the headless tag leave out the files
+that import it (see headless.go), so they run on machines without a display.
+
+*/
+
+// hasWindow is false in headless builds, which always run headless
+const hasWindow = true
+
+func runWindow() error {
+	opts := eb.RunGameOptions{
+		GraphicsLibrary:   eb.GraphicsLibraryAuto,
+		InitUnfocused:     false,
+		ScreenTransparent: false,
+		SkipTaskbar:       false,
+	}
+
+	if err := eb.RunGameWithOptions(&brut, &opts); err != nil && !errors.Is(err, errExit) {
+		return err
+	}
+
+	return nil
+}
+
+func (b *BrutEngine) Draw(dest *eb.Image) {
+	if b.Graphics.target != nil {
+		b.wasm.CallRender()
+		b.Graphics.Present(dest)
+	}
+
+	if b.reloadError != "" {
+		drawOverlay(dest, b.reloadError)
+	}
+}
+
+// Layout keeps the screen the same size as the window, Graphics.Present scales the render target to fit
+func (b *BrutEngine) Layout(dw, dh int) (rw, rh int) {
+	return dw, dh
+}
+
+func (p *Platform) setupWindow() {
+	eb.SetWindowSize(p.ScreenWidth, p.ScreenHeight)
+	eb.SetWindowResizingMode(eb.WindowResizingModeEnabled)
+	eb.SetFullscreen(brut.Config.Fullscreen)
+	eb.SetTPS(brut.Config.TickRate)
+}
+
+func (p *Platform) resizeWindow() {
+	if eb.IsFullscreen() {
+		eb.SetFullscreen(false)
+	}
+
+	eb.SetWindowResizingMode(eb.WindowResizingModeDisabled) // fixes issue with resizing
+	eb.SetWindowSize(p.ScreenWidth, p.ScreenHeight)
+	eb.SetWindowResizingMode(eb.WindowResizingModeEnabled)
+}
+
+func setWindowTitle(title string) {
+	eb.SetWindowTitle(title)
+}
+
+func windowFps() float32 {
+	return float32(eb.ActualFPS())
+}
+
+func windowTps() float32 {
+	return float32(eb.ActualTPS())
+}
@@ -1,4 +1,3 @@
 #!/usr/bin/env sh
 
-tinygo build -o game.wasm -target=wasm -opt=2 -no-debug -panic=trap -scheduler=none . &&\
-mv game.wasm ../../
+tinygo build -o game.wasm -target=wasm -opt=2 -no-debug -panic=trap -scheduler=none .
@@ -22,6 +22,7 @@ var (
 	entityTex         brut.Texture
 
 	entities = make([]Entity, defaultEntities)
+	commands []brut.DrawCommand
 	colors   = []brut.Color{
 		brut.Color{1, 0.25, 0.25, 1},
 		brut.Color{0.25, 1, 0.25, 1},
@@ -73,12 +74,12 @@ var timer = 1.0
 
 //go:export Update
 func update() {
-	if brut.InputPressed(brut.InputEventEscape) {
+	if brut.InputJustPressed(brut.InputEventEscape) {
 		brut.PlatformExit()
 	}
 
 	timer -= 0.1
-	if timer <= 0 || brut.InputDown(brut.InputEventMouseLeft) {
+	if timer <= 0 || brut.InputHeld(brut.InputEventMouseLeft) {
 		x := brut.InputCursorX()
 		y := brut.InputCursorY()
 
@@ -134,10 +135,23 @@ func update() {
 func render() {
 	brut.GraphicsClear(.12, .12, .12, 1)
 
+	commands = commands[:0]
 	for _, e := range entities {
-		brut.GraphicsTextureEx(entityTex, e.x, e.y, 0, 1, 1, e.c.C1, e.c.C2, e.c.C3, e.c.C4*e.t)
+		c := e.c
+		c.C4 *= e.t
+
+		commands = append(commands, brut.DrawCommand{
+			D1:  entityTex,
+			D2:  e.x,
+			D3:  e.y,
+			D7:  1,
+			D8:  1,
+			D10: c,
+		})
 	}
 
+	brut.GraphicsSubmit(commands)
+
 	brut.GraphicsRectangle(10, 10, 120, 60, 0, 0, 0, 0.5, false)
 
 	fps := strconv.FormatFloat(float64(brut.PlatformFps()), 'f', 2, 32)
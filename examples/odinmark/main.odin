@@ -17,7 +17,7 @@ teardown :: proc "c" () {
 
 @(export)
 update :: proc "c" () {
-   if brut.InputPressed(.Escape) {
+   if brut.InputJustPressed(.Escape) {
       brut.PlatformExit()
    }
 }
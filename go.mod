@@ -2,18 +2,25 @@ module github.com/judah-caruso/brutengine
 
 go 1.21.0
 
+require (
+	github.com/fsnotify/fsnotify v1.6.0
+	github.com/hajimehoshi/ebiten/v2 v2.5.10
+	github.com/pkg/profile v1.7.0
+	github.com/tetratelabs/wazero v1.5.0
+	golang.org/x/image v0.12.0
+)
+
 require (
 	github.com/ebitengine/purego v0.4.1 // indirect
 	github.com/felixge/fgprof v0.9.3 // indirect
-	github.com/fsnotify/fsnotify v1.6.0 // indirect
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20221017161538-93cebf72946b // indirect
 	github.com/google/pprof v0.0.0-20211214055906-6f57359322fd // indirect
-	github.com/hajimehoshi/ebiten/v2 v2.5.10 // indirect
+	github.com/hajimehoshi/go-mp3 v0.3.4 // indirect
+	github.com/hajimehoshi/oto/v2 v2.4.2 // indirect
 	github.com/jezek/xgb v1.1.0 // indirect
-	github.com/pkg/profile v1.7.0 // indirect
-	github.com/tetratelabs/wazero v1.5.0 // indirect
+	github.com/jfreymuth/oggvorbis v1.0.5 // indirect
+	github.com/jfreymuth/vorbis v1.0.2 // indirect
 	golang.org/x/exp v0.0.0-20190731235908-ec7cb31e5a56 // indirect
-	golang.org/x/image v0.12.0 // indirect
 	golang.org/x/mobile v0.0.0-20230922142353-e2f452493d57 // indirect
 	golang.org/x/sync v0.3.0 // indirect
 	golang.org/x/sys v0.12.0 // indirect
@@ -1,21 +1,188 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/judah-caruso/brutengine/engine"
 )
 
+const usage = `usage: brutengine <command> [flags] [module.wasm]
+
+commands:
+	run     runs a game module (default: game.wasm)
+	golden  runs a game module headless and compares its last frame to a golden image
+	help    prints this message
+
+run 'brutengine <command> -h' for a list of flags`
+
 func main() {
-	err := engine.Setup()
+	args := os.Args[1:]
+
+	// Running without a command behaves like 'run'
+	cmd := "run"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd = args[0]
+		args = args[1:]
+	}
+
+	var err error
+
+	switch cmd {
+	case "run":
+		err = run(args)
+	case "golden":
+		err = golden(args)
+	case "help":
+		fmt.Println(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", cmd, usage)
+		os.Exit(2)
+	}
+
 	if err != nil {
 		log.Fatal(err)
 	}
+}
+
+// engineFlags are shared by every command that starts the engine
+type engineFlags struct {
+	*flag.FlagSet
+
+	cfg      engine.Config
+	logLevel string
+}
+
+func newEngineFlags(cmd, positional string) *engineFlags {
+	f := &engineFlags{FlagSet: flag.NewFlagSet(cmd, flag.ExitOnError)}
+	f.StringVar(&f.cfg.AssetRoot, "assets", "", "directory assets are loaded relative to")
+	f.IntVar(&f.cfg.WindowWidth, "width", 960, "initial width of the window and render target")
+	f.IntVar(&f.cfg.WindowHeight, "height", 540, "initial height of the window and render target")
+	f.BoolVar(&f.cfg.Fullscreen, "fullscreen", false, "start in fullscreen")
+	f.IntVar(&f.cfg.TickRate, "tps", 60, "ticks per second")
+	f.BoolVar(&f.cfg.Headless, "headless", false, "run without a window, rendering in software")
+	f.IntVar(&f.cfg.Frames, "frames", 0, "number of frames to run when headless (0 runs until the module exits)")
+	f.StringVar(&f.cfg.RecordInput, "record", "", "record input to the given file")
+	f.StringVar(&f.cfg.ReplayInput, "replay", "", "replay input from the given file instead of live devices")
+	f.StringVar(&f.cfg.AudioOut, "audio-out", "", "write the audio mix to the given wav file when headless")
+	f.Uint64Var(&f.cfg.Seed, "seed", 0, "seed for Platform.Random (0 picks one at random)")
+	f.StringVar(&f.logLevel, "log-level", "all", "minimum log level (debug, info, warn, error, all, none)")
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "usage: brutengine %s [flags] %s\n", cmd, positional)
+		f.PrintDefaults()
+	}
+
+	return f
+}
+
+// start parses the log level and sets up the engine. The caller is responsible for calling engine.Teardown.
+func (f *engineFlags) start() error {
+	level, err := engine.ParseLogLevel(f.logLevel)
+	if err != nil {
+		return err
+	}
+
+	engine.SetLogLevel(level)
+	return engine.Setup(f.cfg)
+}
+
+func run(args []string) error {
+	flags := newEngineFlags("run", "[module.wasm]")
+	_ = flags.Parse(args)
+
+	switch flags.NArg() {
+	case 0:
+		flags.cfg.Module = "game.wasm"
+	case 1:
+		flags.cfg.Module = flags.Arg(0)
+	default:
+		return fmt.Errorf("expected a single module, was given %d", flags.NArg())
+	}
+
+	err := flags.start()
+	if err != nil {
+		return err
+	}
+
+	defer engine.Teardown()
+
+	return engine.Run()
+}
+
+func golden(args []string) error {
+	var (
+		update    bool
+		tolerance uint
+		maxDiff   int
+	)
+
+	flags := newEngineFlags("golden", "<module.wasm> <golden.png>")
+	flags.BoolVar(&update, "update", false, "write the rendered frame as the new golden image")
+	flags.UintVar(&tolerance, "tolerance", 2, "allowed difference per color channel (0-255)")
+	flags.IntVar(&maxDiff, "max-diff", 0, "number of pixels allowed to exceed the tolerance")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected a module and a golden image, was given %d arguments", flags.NArg())
+	}
+
+	if tolerance > 255 {
+		return fmt.Errorf("tolerance must be between 0 and 255, was given %d", tolerance)
+	}
+
+	// The module would otherwise decide when the frame is taken, or never exit at all
+	if flags.cfg.Frames <= 0 {
+		return fmt.Errorf("golden needs --frames greater than 0, was given %d", flags.cfg.Frames)
+	}
+
+	flags.cfg.Module = flags.Arg(0)
+	flags.cfg.Headless = true
+	goldenPath := flags.Arg(1)
+
+	err := flags.start()
+	if err != nil {
+		return err
+	}
 
 	defer engine.Teardown()
 
 	err = engine.Run()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+
+	frame := engine.Snapshot()
+
+	if update {
+		fmt.Printf("updated %s\n", goldenPath)
+		return engine.WritePNG(goldenPath, frame)
+	}
+
+	want, err := engine.ReadPNG(goldenPath)
+	if err != nil {
+		return err
+	}
+
+	mismatched, err := engine.CompareImages(frame, want, uint8(tolerance))
+	if err != nil {
+		return err
+	}
+
+	if mismatched > maxDiff {
+		actualPath := strings.TrimSuffix(goldenPath, ".png") + ".actual.png"
+
+		err = engine.WritePNG(actualPath, frame)
+		if err != nil {
+			return err
+		}
+
+		return fmt.Errorf("%d pixels differ from %s (rendered frame written to %s)", mismatched, goldenPath, actualPath)
+	}
+
+	fmt.Printf("ok %s (%d pixels differ)\n", goldenPath, mismatched)
+	return nil
 }